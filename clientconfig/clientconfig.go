@@ -0,0 +1,87 @@
+package clientconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/taask/client-golang/config"
+	sconfig "github.com/taask/taask-server/config"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// CurrentVersion and others are consts for the client auth config schema
+const (
+	// CurrentVersion is the config version this taaskctl reads and writes, a config with a lower version must be migrated first
+	CurrentVersion = sconfig.MemberAuthConfigVersion
+	Type           = sconfig.MemberAuthConfigType
+
+	formatYAML = "yaml"
+	formatJSON = "json"
+)
+
+// DefaultAuthPath returns the path of the auth config taaskctl connects with
+func DefaultAuthPath() string {
+	return filepath.Join(config.DefaultClientConfigDir(), config.ConfigClientDefaultFilename)
+}
+
+// Load reads the auth config at path, returning an error if it has an unknown type or needs to be migrated.
+// Unlike config.LocalAuthConfigFromFile, the format is detected rather than falling back from YAML to JSON, so a parse error names the format that failed.
+func Load(path string) (*config.LocalAuthConfig, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to ReadFile")
+	}
+
+	localAuth, _, err := decode(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to decode %s", path))
+	}
+
+	if err := checkType(localAuth); err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+
+	if localAuth.Version > CurrentVersion {
+		return nil, fmt.Errorf("%s is config version %d, but this taaskctl only supports up to version %d, upgrade taaskctl to use it", path, localAuth.Version, CurrentVersion)
+	} else if localAuth.Version < CurrentVersion {
+		return nil, fmt.Errorf("%s is config version %d, run 'taaskctl config migrate %s' to upgrade it to version %d", path, localAuth.Version, path, CurrentVersion)
+	}
+
+	return localAuth, nil
+}
+
+// decode parses a YAML or JSON config, returning the format it was in
+func decode(raw []byte) (*config.LocalAuthConfig, string, error) {
+	localAuth := &config.LocalAuthConfig{}
+
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		if err := json.Unmarshal(raw, localAuth); err != nil {
+			return nil, "", errors.Wrap(err, "failed to json.Unmarshal")
+		}
+
+		return localAuth, formatJSON, nil
+	}
+
+	if err := yaml.Unmarshal(raw, localAuth); err != nil {
+		return nil, "", errors.Wrap(err, "failed to yaml.Unmarshal")
+	}
+
+	return localAuth, formatYAML, nil
+}
+
+// checkType returns an error if the config is for something other than member auth, configs written before the type was added have none
+func checkType(localAuth *config.LocalAuthConfig) error {
+	if localAuth.Type != "" && localAuth.Type != Type {
+		return fmt.Errorf("unknown config type %q, expected %q", localAuth.Type, Type)
+	}
+
+	if localAuth.Type == "" && localAuth.Version >= CurrentVersion {
+		return fmt.Errorf("config version %d has no type, expected %q", localAuth.Version, Type)
+	}
+
+	return nil
+}
@@ -0,0 +1,338 @@
+package clientconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/taask/client-golang/config"
+	"github.com/taask/taaskctl/credential"
+)
+
+const (
+	currentYAML = `clientauthconfig:
+  version: 1
+  type: com.taask.config.memberauth
+  membergroup:
+    name: admin
+  service:
+    type: com.taask.service.client
+`
+
+	legacyJSON = `{"Passphrase": "legacy-pass", "MemberGroup": {"UUID": "u", "Name": "admin"}}`
+
+	legacyYAML = `passphrase: legacy-pass
+clientauthconfig:
+  membergroup:
+    name: admin
+`
+
+	passphraseYAML = `passphrase: current-pass
+clientauthconfig:
+  version: 1
+  type: com.taask.config.memberauth
+  membergroup:
+    name: admin
+  service:
+    type: com.taask.service.client
+`
+
+	runnerYAML = `passphrase: runner-pass
+clientauthconfig:
+  version: 1
+  type: com.taask.config.memberauth
+  membergroup:
+    name: default
+  service:
+    type: com.taask.service.runner
+`
+
+	unknownTypeYAML = `clientauthconfig:
+  version: 1
+  type: com.taask.config.other
+`
+
+	newerYAML = `clientauthconfig:
+  version: 2
+  type: com.taask.config.memberauth
+`
+
+	noTypeYAML = `clientauthconfig:
+  version: 1
+`
+)
+
+// withConfigHome points the client config dir at a temporary dir for the length of a test, and returns the client config dir
+func withConfigHome(t *testing.T) string {
+	home, err := ioutil.TempDir("", "taaskctl-clientconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldHome, hadHome := os.LookupEnv("XDG_CONFIG_HOME")
+	os.Setenv("XDG_CONFIG_HOME", home)
+
+	t.Cleanup(func() {
+		if hadHome {
+			os.Setenv("XDG_CONFIG_HOME", oldHome)
+		} else {
+			os.Unsetenv("XDG_CONFIG_HOME")
+		}
+
+		os.RemoveAll(home)
+	})
+
+	dir := config.DefaultClientConfigDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func writeConfig(t *testing.T, path, contents string, mode os.FileMode) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(contents), mode); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		wantErr  string
+	}{
+		{name: "current version", contents: currentYAML},
+		{name: "current version as JSON", contents: `{"Version": 1, "Type": "com.taask.config.memberauth"}`},
+		{name: "unknown type", contents: unknownTypeYAML, wantErr: "unknown config type"},
+		{name: "newer version", contents: newerYAML, wantErr: "upgrade taaskctl"},
+		{name: "older version", contents: legacyYAML, wantErr: "taaskctl config migrate"},
+		{name: "missing type", contents: noTypeYAML, wantErr: "has no type"},
+		{name: "invalid YAML", contents: "clientauthconfig: [", wantErr: "yaml"},
+		{name: "invalid JSON", contents: `{"Version": `, wantErr: "json"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := withConfigHome(t)
+			path := filepath.Join(dir, "admin-auth.yaml")
+			writeConfig(t, path, test.contents, 0600)
+
+			localAuth, err := Load(path)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", test.wantErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if localAuth.Version != CurrentVersion || localAuth.Type != Type {
+				t.Errorf("got version %d and type %q", localAuth.Version, localAuth.Type)
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name             string
+		contents         string
+		mode             os.FileMode
+		storedPassphrase string
+		outsideClientDir bool
+		wantChanges      []string
+		wantPassphrase   string
+		wantErr          string
+	}{
+		{
+			name:     "current config is left alone",
+			contents: currentYAML,
+			mode:     0600,
+		},
+		{
+			name:           "legacy JSON config",
+			contents:       legacyJSON,
+			mode:           0644,
+			wantChanges:    []string{"upgraded from version 0 to 1", "converted from JSON to YAML", "moved passphrase to encrypted storage", "restricted mode to -rw-------"},
+			wantPassphrase: "legacy-pass",
+		},
+		{
+			name:           "legacy YAML config",
+			contents:       legacyYAML,
+			mode:           0600,
+			wantChanges:    []string{"upgraded from version 0 to 1", "moved passphrase to encrypted storage"},
+			wantPassphrase: "legacy-pass",
+		},
+		{
+			name:             "passphrase matching the stored one",
+			contents:         passphraseYAML,
+			mode:             0600,
+			storedPassphrase: "current-pass",
+			wantChanges:      []string{"moved passphrase to encrypted storage"},
+			wantPassphrase:   "current-pass",
+		},
+		{
+			name:        "only the mode is wrong",
+			contents:    currentYAML,
+			mode:        0640,
+			wantChanges: []string{"restricted mode to -rw-------"},
+		},
+		{
+			name:        "missing type",
+			contents:    noTypeYAML,
+			mode:        0600,
+			wantChanges: []string{"set type to com.taask.config.memberauth"},
+		},
+		{
+			name:             "passphrase differing from the stored one",
+			contents:         passphraseYAML,
+			mode:             0600,
+			storedPassphrase: "other-pass",
+			wantErr:          "differs from the one already stored",
+		},
+		{
+			name:     "runner config",
+			contents: runnerYAML,
+			mode:     0600,
+			wantErr:  "only com.taask.service.client configs can be migrated",
+		},
+		{
+			name:             "config without a service outside the client config dir",
+			contents:         legacyYAML,
+			mode:             0600,
+			outsideClientDir: true,
+			wantErr:          "only client configs can be migrated",
+		},
+		{
+			name:     "unknown type",
+			contents: unknownTypeYAML,
+			mode:     0600,
+			wantErr:  "unknown config type",
+		},
+		{
+			name:     "newer version",
+			contents: newerYAML,
+			mode:     0600,
+			wantErr:  "only supports up to version",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := withConfigHome(t)
+			if test.outsideClientDir {
+				dir = filepath.Join(dir, "elsewhere")
+			}
+
+			path := filepath.Join(dir, "admin-auth.yaml")
+			writeConfig(t, path, test.contents, test.mode)
+
+			if test.storedPassphrase != "" {
+				if err := credential.Store(path, test.storedPassphrase); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := Migrate(path)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", test.wantErr, err)
+				}
+
+				raw, err := ioutil.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if string(raw) != test.contents {
+					t.Errorf("config was changed by a failed migration:\n%s", raw)
+				}
+
+				if _, err := os.Stat(path + BackupExtension); !os.IsNotExist(err) {
+					t.Errorf("a failed migration wrote a backup")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if strings.Join(result.Changes, "\n") != strings.Join(test.wantChanges, "\n") {
+				t.Errorf("got changes %q, want %q", result.Changes, test.wantChanges)
+			}
+
+			if len(test.wantChanges) == 0 {
+				if result.BackupPath != "" {
+					t.Errorf("a config without changes was backed up to %s", result.BackupPath)
+				}
+
+				return
+			}
+
+			// the migrated config loads, and keeps nothing secret in plaintext
+			localAuth, err := Load(path)
+			if err != nil {
+				t.Fatalf("failed to Load the migrated config: %s", err)
+			}
+
+			if localAuth.Passphrase != "" {
+				t.Error("the migrated config contains the passphrase")
+			}
+
+			if err := credential.CheckMode(path); err != nil {
+				t.Error(err)
+			}
+
+			if test.wantPassphrase != "" {
+				passphrase, err := credential.Load(path)
+				if err != nil {
+					t.Fatalf("failed to Load the stored passphrase: %s", err)
+				}
+
+				if passphrase != test.wantPassphrase {
+					t.Errorf("stored passphrase is %q, want %q", passphrase, test.wantPassphrase)
+				}
+			}
+
+			backup, err := ioutil.ReadFile(result.BackupPath)
+			if err != nil {
+				t.Fatalf("failed to read the backup: %s", err)
+			}
+
+			if strings.Contains(strings.ToLower(string(backup)), "passphrase") {
+				t.Errorf("the backup contains the passphrase:\n%s", backup)
+			}
+
+			if err := credential.CheckMode(result.BackupPath); err != nil {
+				t.Error(err)
+			}
+
+			// migrating again changes nothing
+			again, err := Migrate(path)
+			if err != nil {
+				t.Fatalf("failed to migrate again: %s", err)
+			}
+
+			if len(again.Changes) != 0 {
+				t.Errorf("migrating again made changes %q", again.Changes)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package clientconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/taask/client-golang/config"
+	sconfig "github.com/taask/taask-server/config"
+	"github.com/taask/taaskctl/credential"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// BackupExtension and others are consts for migrating configs
+const (
+	// BackupExtension is appended to the path of a config to back it up before it is migrated
+	BackupExtension = ".bak"
+
+	passphraseKey = "passphrase"
+)
+
+// migrations upgrade a config from the version they are keyed by to the next version
+var migrations = map[int]func(*config.LocalAuthConfig) error{
+	// configs written before the schema was versioned have neither a version nor a type
+	0: func(localAuth *config.LocalAuthConfig) error {
+		localAuth.Version = 1
+		localAuth.Type = Type
+
+		return nil
+	},
+}
+
+// MigrateResult describes the changes made to a config by Migrate
+type MigrateResult struct {
+	Changes    []string
+	BackupPath string
+}
+
+// Migrate upgrades the client auth config at path to CurrentVersion in place, backing up the original (without its passphrase) first.
+// It also converts JSON configs to YAML, moves a plaintext passphrase into encrypted storage and restricts the file's mode.
+// If the config needs no changes, it is left alone and the result has no changes.
+func Migrate(path string) (*MigrateResult, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to ReadFile")
+	}
+
+	localAuth, format, err := decode(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to decode %s", path))
+	}
+
+	if localAuth.Type != "" && localAuth.Type != Type {
+		return nil, fmt.Errorf("%s has unknown config type %q, expected %q", path, localAuth.Type, Type)
+	}
+
+	if localAuth.Version > CurrentVersion {
+		return nil, fmt.Errorf("%s is config version %d, but this taaskctl only supports up to version %d", path, localAuth.Version, CurrentVersion)
+	}
+
+	if err := checkClient(path, localAuth); err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+
+	result := &MigrateResult{Changes: []string{}}
+
+	for version := localAuth.Version; version < CurrentVersion; version++ {
+		migration, ok := migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration from config version %d", version)
+		}
+
+		if err := migration(localAuth); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to migrate from version %d", version))
+		}
+
+		result.Changes = append(result.Changes, fmt.Sprintf("upgraded from version %d to %d", version, version+1))
+	}
+
+	if localAuth.Type == "" {
+		localAuth.Type = Type
+		result.Changes = append(result.Changes, fmt.Sprintf("set type to %s", Type))
+	}
+
+	if format == formatJSON {
+		result.Changes = append(result.Changes, "converted from JSON to YAML")
+	}
+
+	passphrase := localAuth.Passphrase
+	if passphrase != "" {
+		stored, err := credential.Load(path)
+		if err == nil && stored != passphrase {
+			return nil, fmt.Errorf("%s contains a passphrase that differs from the one already stored for it, remove one of them and migrate again", path)
+		} else if err != nil && !os.IsNotExist(errors.Cause(err)) {
+			return nil, errors.Wrap(err, "failed to Load passphrase")
+		}
+
+		localAuth.Passphrase = ""
+		result.Changes = append(result.Changes, "moved passphrase to encrypted storage")
+	}
+
+	if err := credential.CheckMode(path); err != nil {
+		result.Changes = append(result.Changes, fmt.Sprintf("restricted mode to %s", credential.FileMode))
+	}
+
+	if len(result.Changes) == 0 {
+		return result, nil
+	}
+
+	// the passphrase is kept encrypted by Store, so the backup doesn't need it in plaintext
+	backup := raw
+	if passphrase != "" {
+		backup, err = removePassphrase(raw, format)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to removePassphrase")
+		}
+	}
+
+	result.BackupPath = path + BackupExtension
+	if err := credential.WriteFile(result.BackupPath, backup); err != nil {
+		return nil, errors.Wrap(err, "failed to write backup")
+	}
+
+	if passphrase != "" {
+		if err := credential.Store(path, passphrase); err != nil {
+			return nil, errors.Wrap(err, "failed to Store passphrase")
+		}
+	}
+
+	rawYAML, err := yaml.Marshal(localAuth)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to yaml.Marshal")
+	}
+
+	if err := credential.WriteFile(path, rawYAML); err != nil {
+		return nil, errors.Wrap(err, "failed to WriteFile")
+	}
+
+	return result, nil
+}
+
+// checkClient returns an error if the config isn't one taaskctl connects with. taask-server and the runner read
+// their configs with client-golang, which can't read a passphrase moved to encrypted storage, so they must not be migrated.
+// Configs written before the service was added are only migrated if they are in the client config dir.
+func checkClient(path string, localAuth *config.LocalAuthConfig) error {
+	if localAuth.Service != nil {
+		if localAuth.Service.Type != sconfig.ServiceTypeClient {
+			return fmt.Errorf("config is for service type %s, only %s configs can be migrated", localAuth.Service.Type, sconfig.ServiceTypeClient)
+		}
+
+		return nil
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return errors.Wrap(err, "failed to Abs")
+	}
+
+	clientDir, err := filepath.Abs(config.DefaultClientConfigDir())
+	if err != nil {
+		return errors.Wrap(err, "failed to Abs")
+	}
+
+	if dir != clientDir {
+		return fmt.Errorf("config has no service and is not in %s, only client configs can be migrated", clientDir)
+	}
+
+	return nil
+}
+
+// removePassphrase removes the passphrase from a raw config, keeping the rest of it as it was
+func removePassphrase(raw []byte, format string) ([]byte, error) {
+	if format == formatJSON {
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, errors.Wrap(err, "failed to json.Unmarshal")
+		}
+
+		for key := range fields {
+			if strings.EqualFold(key, passphraseKey) {
+				delete(fields, key)
+			}
+		}
+
+		return json.MarshalIndent(fields, "", "  ")
+	}
+
+	fields := yaml.MapSlice{}
+	if err := yaml.Unmarshal(raw, &fields); err != nil {
+		return nil, errors.Wrap(err, "failed to yaml.Unmarshal")
+	}
+
+	withoutPassphrase := yaml.MapSlice{}
+	for _, field := range fields {
+		if key, ok := field.Key.(string); !ok || key != passphraseKey {
+			withoutPassphrase = append(withoutPassphrase, field)
+		}
+	}
+
+	return yaml.Marshal(withoutPassphrase)
+}
@@ -22,7 +22,7 @@ type answer struct {
 	Answer int
 }
 
-func chaosCmd(client *taask.Client) *cobra.Command {
+func chaosCmd(connect ClientFunc) *cobra.Command {
 	var numTasks *int
 
 	cmd := &cobra.Command{
@@ -30,8 +30,9 @@ func chaosCmd(client *taask.Client) *cobra.Command {
 		Short: "chaos runs load/correctness testing on a Taask installation.",
 		Long:  `chaos queues 1000 tasks of Kind io.taask.k8s, waits for them to complete, and prints stats about the run`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if client == nil {
-				log.LogError(errors.New("unable to connect"))
+			client, err := connect()
+			if err != nil {
+				log.LogError(errors.Wrap(err, "unable to connect"))
 				return
 			}
 
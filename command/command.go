@@ -5,19 +5,36 @@ import (
 	taask "github.com/taask/client-golang"
 )
 
-// Build builds the command tree
-func Build(client *taask.Client) *cobra.Command {
+// ClientFunc connects to the server, it is only called by commands that need a client
+type ClientFunc func() (*taask.Client, error)
+
+// Build builds the command tree, context names the server that connect connects to
+func Build(connect ClientFunc, context string) *cobra.Command {
 	root := rootCmd()
 
 	// Generate auth for deploying taask
 	root.AddCommand(initCmd())
+	root.AddCommand(configCmd())
 
 	// Task commands
-	root.AddCommand(createCmd(client))
-	root.AddCommand(getCmd(client))
+	root.AddCommand(createCmd(connect, context))
+	root.AddCommand(getCmd(connect))
+	root.AddCommand(historyCmd(connect))
+
+	// Multi-task commands
+	root.AddCommand(workflowCmd(connect, context))
+	root.AddCommand(mapCmd(connect, context))
+
+	// Observability
+	root.AddCommand(topCmd())
+	root.AddCommand(statsCmd())
 
 	// Load testing
-	root.AddCommand(chaosCmd(client))
+	root.AddCommand(chaosCmd(connect))
+
+	// Shell completion
+	root.AddCommand(completionCmd())
+	root.AddCommand(completeCmd(context))
 
 	return root
 }
@@ -0,0 +1,399 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	log "github.com/cohix/simplog"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	taask "github.com/taask/client-golang"
+	"github.com/taask/taaskctl/history"
+)
+
+// completion candidate types, generated completion scripts call 'taaskctl __complete [type]' to list them
+const (
+	completeTasks    = "tasks"
+	completeKinds    = "kinds"
+	completeContexts = "contexts"
+)
+
+// bashCompletionFunc is included in the generated bash completion, cobra calls __custom_func when it has nothing to complete
+const bashCompletionFunc = `
+__taaskctl_complete()
+{
+    local taaskctl_out
+    if taaskctl_out=$(taaskctl __complete "$1" 2>/dev/null); then
+        COMPREPLY=( $( compgen -W "${taaskctl_out[*]}" -- "$cur" ) )
+    fi
+}
+
+__custom_func() {
+    case ${last_command} in
+        taaskctl_get | taaskctl_history_alias)
+            if [[ ${#nouns[@]} -eq 0 ]]; then
+                __taaskctl_complete ` + completeTasks + `
+            fi
+            return
+            ;;
+        *)
+            ;;
+    esac
+}
+`
+
+// taskRefCommands are the commands whose first argument is a task reference
+var taskRefCommands = []string{"get", "history alias"}
+
+// fileArgCommands are the commands whose arguments are files, fish completes files for them and for no other command
+var fileArgCommands = []string{"create", "workflow run", "config migrate"}
+
+func completionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "completion [bash | zsh | fish]",
+		Short: "outputs a shell completion script.",
+		Long: `completion outputs a completion script for bash, zsh or fish.
+Task UUIDs are completed from the local history, and --kind and --context from known kinds and servers.
+
+To load completions for the current bash session: source <(taaskctl completion bash)
+To load completions for the current zsh session: source <(taaskctl completion zsh)
+To load completions for fish: taaskctl completion fish > ~/.config/fish/completions/taaskctl.fish`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"bash", "zsh", "fish"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+
+			switch args[0] {
+			case "bash":
+				err = cmd.Root().GenBashCompletion(os.Stdout)
+			case "zsh":
+				err = genZshCompletion(cmd.Root(), os.Stdout)
+			case "fish":
+				err = genFishCompletion(cmd.Root(), os.Stdout)
+			default:
+				err = fmt.Errorf("unsupported shell %s", args[0])
+			}
+
+			if err != nil {
+				log.LogError(errors.Wrap(err, "failed to generate completion"))
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+func completeCmd(context string) *cobra.Command {
+	return &cobra.Command{
+		Use:    "__complete [tasks | kinds | contexts]",
+		Short:  "lists completion candidates for the generated completion scripts.",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			// errors are ignored, a completion script has nowhere to show them
+			entries, _ := history.Load()
+
+			candidates := []string{}
+
+			switch args[0] {
+			case completeTasks:
+				if len(entries) > 0 {
+					candidates = append(candidates, history.RefLast)
+				}
+
+				// most recent first, so that the likeliest tasks are listed first
+				for i := len(entries) - 1; i >= 0; i-- {
+					if entries[i].Alias != "" {
+						candidates = append(candidates, history.RefPrefix+entries[i].Alias)
+					}
+
+					candidates = append(candidates, entries[i].UUID)
+				}
+			case completeKinds:
+				candidates = append(candidates, taask.TaskKindK8s)
+				for _, entry := range entries {
+					candidates = append(candidates, entry.Kind)
+				}
+			case completeContexts:
+				candidates = append(candidates, context)
+				for _, entry := range entries {
+					candidates = append(candidates, entry.Context)
+				}
+			}
+
+			seen := make(map[string]bool)
+			for _, c := range candidates {
+				if c != "" && !seen[c] {
+					seen[c] = true
+					fmt.Println(c)
+				}
+			}
+		},
+	}
+}
+
+// markFlagCompletion makes a flag complete from a candidate type in all of the generated completion scripts
+func markFlagCompletion(cmd *cobra.Command, flag, candidates string) {
+	cmd.Flags().SetAnnotation(flag, cobra.BashCompCustom, []string{fmt.Sprintf("__taaskctl_complete %s", candidates)})
+}
+
+// zshInitialization defines zsh versions of the bash builtins and bash-completion helpers that cobra's bash completion uses,
+// the bash completion is then converted to call them and sourced with bashcompinit. This follows kubectl's zsh completion,
+// since cobra's own zsh completion has no support for completing arguments dynamically
+const zshInitialization = `
+__taaskctl_bash_source() {
+	alias shopt=':'
+	alias _expand=_bash_expand
+	alias _complete=_bash_comp
+	emulate -L sh
+	setopt kshglob noshglob braceexpand
+
+	source "$@"
+}
+
+__taaskctl_type() {
+	# -t is not supported by zsh
+	if [ "$1" == "-t" ]; then
+		shift
+
+		# fake bash 4 to disable "complete -o nospace", trailing spaces are always left on
+		if [ "$1" = "__taaskctl_compopt" ]; then
+			echo builtin
+			return 0
+		fi
+	fi
+	type "$@"
+}
+
+__taaskctl_compgen() {
+	local completions w
+	completions=( $(compgen "$@") ) || return $?
+
+	# filter by given word as prefix
+	while [[ "$1" = -* && "$1" != -- ]]; do
+		shift
+		shift
+	done
+	if [[ "$1" == -- ]]; then
+		shift
+	fi
+	for w in "${completions[@]}"; do
+		if [[ "${w}" = "$1"* ]]; then
+			echo "${w}"
+		fi
+	done
+}
+
+__taaskctl_compopt() {
+	true # not supported by bashcompinit in zsh
+}
+
+__taaskctl_ltrim_colon_completions()
+{
+	if [[ "$1" == *:* && "$COMP_WORDBREAKS" == *:* ]]; then
+		# remove colon-word prefix from COMPREPLY items
+		local colon_word=${1%${1##*:}}
+		local i=${#COMPREPLY[*]}
+		while [[ $((--i)) -ge 0 ]]; do
+			COMPREPLY[$i]=${COMPREPLY[$i]#"$colon_word"}
+		done
+	fi
+}
+
+__taaskctl_get_comp_words_by_ref() {
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	prev="${COMP_WORDS[${COMP_CWORD}-1]}"
+	words=("${COMP_WORDS[@]}")
+	cword=("${COMP_CWORD[@]}")
+}
+
+__taaskctl_filedir() {
+	local RET OLD_IFS w qw
+
+	if [[ "$1" = \~* ]]; then
+		eval echo "$1"
+		return 0
+	fi
+
+	OLD_IFS="$IFS"
+	IFS=$'\n'
+	if [ "$1" = "-d" ]; then
+		shift
+		RET=( $(compgen -d) )
+	else
+		RET=( $(compgen -f) )
+	fi
+	IFS="$OLD_IFS"
+
+	for w in ${RET[@]}; do
+		if [[ ! "${w}" = "${cur}"* ]]; then
+			continue
+		fi
+		if eval "[[ \"\${w}\" = *.$1 || -d \"\${w}\" ]]"; then
+			qw="$(__taaskctl_quote "${w}")"
+			if [ -d "${w}" ]; then
+				COMPREPLY+=("${qw}/")
+			else
+				COMPREPLY+=("${qw}")
+			fi
+		fi
+	done
+}
+
+__taaskctl_quote() {
+	if [[ $1 == \'* || $1 == \"* ]]; then
+		# leave out first character
+		printf %q "${1:1}"
+	else
+		printf %q "$1"
+	fi
+}
+
+autoload -U +X bashcompinit && bashcompinit
+`
+
+// zshConversions rewrite cobra's bash completion to run in zsh, in order
+var zshConversions = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`declare -F`), "whence -w"},
+	{regexp.MustCompile(`_get_comp_words_by_ref "\$@"`), `_get_comp_words_by_ref "$$*"`},
+	{regexp.MustCompile(`local ([a-zA-Z0-9_]*)=`), "local $1; $1="},
+	{regexp.MustCompile(`flags\+=\("(--.*)="\)`), `flags+=("$1"); two_word_flags+=("$1")`},
+	{regexp.MustCompile(`must_have_one_flag\+=\("(--.*)="\)`), `must_have_one_flag+=("$1")`},
+	{regexp.MustCompile(`\b_filedir\b`), "__taaskctl_filedir"},
+	{regexp.MustCompile(`\b_get_comp_words_by_ref\b`), "__taaskctl_get_comp_words_by_ref"},
+	{regexp.MustCompile(`\b__ltrim_colon_completions\b`), "__taaskctl_ltrim_colon_completions"},
+	{regexp.MustCompile(`\bcompgen\b`), "__taaskctl_compgen"},
+	{regexp.MustCompile(`\bcompopt\b`), "__taaskctl_compopt"},
+	{regexp.MustCompile(`\bdeclare\b`), "builtin declare"},
+	{regexp.MustCompile(`\$\(type\b`), "$$(__taaskctl_type"},
+}
+
+// genZshCompletion converts the bash completion to run in zsh with bashcompinit
+func genZshCompletion(root *cobra.Command, w io.Writer) error {
+	bashBuf := new(bytes.Buffer)
+	if err := root.GenBashCompletion(bashBuf); err != nil {
+		return errors.Wrap(err, "failed to GenBashCompletion")
+	}
+
+	bash := bashBuf.String()
+	for _, conversion := range zshConversions {
+		bash = conversion.pattern.ReplaceAllString(bash, conversion.replacement)
+	}
+
+	buf := new(bytes.Buffer)
+
+	buf.WriteString("#compdef taaskctl\n")
+	buf.WriteString(zshInitialization)
+	buf.WriteString("\n__taaskctl_bash_script() {\n\tcat <<'BASH_COMPLETION_EOF'\n")
+	buf.WriteString(bash)
+	buf.WriteString("BASH_COMPLETION_EOF\n}\n\n")
+	buf.WriteString("__taaskctl_bash_source <(__taaskctl_bash_script)\n")
+	buf.WriteString("_complete taaskctl 2>/dev/null\n")
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// genFishCompletion writes a fish completion script for the command tree
+func genFishCompletion(root *cobra.Command, w io.Writer) error {
+	buf := new(bytes.Buffer)
+
+	name := root.Name()
+
+	buf.WriteString(fmt.Sprintf("function __%s_complete\n    %s __complete $argv 2>/dev/null\nend\n\n", name, name))
+
+	var walk func(cmd *cobra.Command, path []string)
+	walk = func(cmd *cobra.Command, path []string) {
+		condition := fishCondition(path)
+
+		for _, sub := range cmd.Commands() {
+			if !sub.IsAvailableCommand() {
+				continue
+			}
+
+			buf.WriteString(fmt.Sprintf("complete -c %s -n '%s' -a %s -d %s\n", name, condition, sub.Name(), fishQuote(sub.Short)))
+		}
+
+		// fish completes files for every command unless told not to
+		if stringIn(strings.Join(path, " "), fileArgCommands) {
+			buf.WriteString(fmt.Sprintf("complete -c %s -n '%s' -F\n", name, condition))
+		} else {
+			buf.WriteString(fmt.Sprintf("complete -c %s -n '%s' -f\n", name, condition))
+		}
+
+		if len(path) > 0 && !cmd.HasSubCommands() {
+			if stringIn(strings.Join(path, " "), taskRefCommands) {
+				buf.WriteString(fmt.Sprintf("complete -c %s -n '%s' -a '(__%s_complete %s)'\n", name, condition, name, completeTasks))
+			}
+
+			for _, arg := range cmd.ValidArgs {
+				buf.WriteString(fmt.Sprintf("complete -c %s -n '%s' -a %s\n", name, condition, arg))
+			}
+		}
+
+		cmd.NonInheritedFlags().VisitAll(func(flag *pflag.Flag) {
+			if flag.Hidden || flag.Name == "help" {
+				return
+			}
+
+			line := fmt.Sprintf("complete -c %s -n '%s' -l %s", name, condition, flag.Name)
+			if flag.Shorthand != "" {
+				line += fmt.Sprintf(" -s %s", flag.Shorthand)
+			}
+
+			if handlers, ok := flag.Annotations[cobra.BashCompCustom]; ok && len(handlers) > 0 {
+				candidates := strings.TrimPrefix(handlers[0], "__taaskctl_complete ")
+				line += fmt.Sprintf(" -x -a '(__%s_complete %s)'", name, candidates)
+			} else if _, ok := flag.Annotations[cobra.BashCompFilenameExt]; ok {
+				line += " -r -F"
+			} else if flag.Value.Type() != "bool" {
+				line += " -x"
+			}
+
+			buf.WriteString(line + fmt.Sprintf(" -d %s\n", fishQuote(flag.Usage)))
+		})
+
+		for _, sub := range cmd.Commands() {
+			if sub.IsAvailableCommand() {
+				walk(sub, append(append([]string{}, path...), sub.Name()))
+			}
+		}
+	}
+
+	walk(root, []string{})
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// fishCondition returns a fish condition that is true when the commands in path are the first words typed
+func fishCondition(path []string) string {
+	if len(path) == 0 {
+		return "__fish_use_subcommand"
+	}
+
+	words := strings.Join(path, " ")
+
+	return fmt.Sprintf(`test (string join " " (commandline -opc)[2..%d]) = "%s"`, len(path)+1, words)
+}
+
+func fishQuote(s string) string {
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "$", "\\$").Replace(s) + "\""
+}
+
+func stringIn(s string, list []string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,65 @@
+package command
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/cohix/simplog"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/taask/taaskctl/clientconfig"
+)
+
+func configCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "manages taaskctl's auth config files.",
+	}
+
+	cmd.AddCommand(configMigrateCmd())
+
+	return cmd
+}
+
+func configMigrateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate [file...]",
+		Short: "upgrades auth config files to the current config version.",
+		Long: fmt.Sprintf(`migrate upgrades each auth config file (by default %s) in place to config version %d,
+converting JSON configs to YAML, moving a plaintext passphrase into encrypted storage and restricting the file to mode 0600.
+The original file is kept next to it with a %s extension, without its passphrase.
+Only configs that taaskctl connects with can be migrated, taask-server and runner configs are refused.`, clientconfig.DefaultAuthPath(), clientconfig.CurrentVersion, clientconfig.BackupExtension),
+		Run: func(cmd *cobra.Command, args []string) {
+			paths := args
+			if len(paths) == 0 {
+				paths = []string{clientconfig.DefaultAuthPath()}
+			}
+
+			failed := false
+
+			for _, path := range paths {
+				result, err := clientconfig.Migrate(path)
+				if err != nil {
+					log.LogError(errors.Wrap(err, fmt.Sprintf("failed to migrate %s", path)))
+					failed = true
+					continue
+				}
+
+				if len(result.Changes) == 0 {
+					log.LogInfo(fmt.Sprintf("%s is up to date", path))
+					continue
+				}
+
+				for _, change := range result.Changes {
+					log.LogInfo(fmt.Sprintf("%s: %s", path, change))
+				}
+
+				log.LogInfo(fmt.Sprintf("%s: backed up to %s", path, result.BackupPath))
+			}
+
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+}
@@ -9,10 +9,11 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	taask "github.com/taask/client-golang"
+	"github.com/taask/taask-server/model"
 	"github.com/taask/taaskctl/readwrite"
 )
 
-func createCmd(client *taask.Client) *cobra.Command {
+func createCmd(connect ClientFunc, context string) *cobra.Command {
 	var watch *bool
 	var ugly *bool
 
@@ -23,12 +24,12 @@ func createCmd(client *taask.Client) *cobra.Command {
 The task can be formatted as JSON or YAML.
 The task UUID is returned.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if client == nil {
-				log.LogError(errors.New("unable to connect"))
+			client, err := connect()
+			if err != nil {
+				log.LogError(errors.Wrap(err, "unable to connect"))
 				return
 			}
 
-			var err error
 			var task *taask.Task
 
 			if args[0] == "-" {
@@ -53,6 +54,8 @@ The task UUID is returned.`,
 				os.Exit(1)
 			}
 
+			recordSubmission(context, uuid, specSource(args[0]), *task)
+
 			if *watch {
 				watchResult(client, uuid, *ugly)
 				return
@@ -76,6 +79,8 @@ func watchResult(client *taask.Client, uuid string, ugly bool) {
 		os.Exit(1)
 	}
 
+	updateHistoryStatus(uuid, model.TaskStatusCompleted)
+
 	if ugly {
 		fmt.Println(string(result))
 		return
@@ -7,27 +7,29 @@ import (
 	log "github.com/cohix/simplog"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
-	taask "github.com/taask/client-golang"
 	"github.com/taask/taask-server/model"
 )
 
-func getCmd(client *taask.Client) *cobra.Command {
+func getCmd(connect ClientFunc) *cobra.Command {
 	var watch *bool
 	var ugly *bool
 
 	cmd := &cobra.Command{
-		Use:   "get [uuid]",
+		Use:   "get [uuid | prefix | @last | @alias]",
 		Short: "gets the results of a task.",
 		Long: `get fetches the status of task [uuid].
 If the task is not complete, the task's status is printed.
-If the task is complete, the result JSON is printed.`,
+If the task is complete, the result JSON is printed.
+Tasks in the local history can be referred to by a unique UUID prefix, @last, or an alias.`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			if client == nil {
-				log.LogError(errors.New("unable to connect"))
+			client, err := connect()
+			if err != nil {
+				log.LogError(errors.Wrap(err, "unable to connect"))
 				return
 			}
 
-			uuid := args[0]
+			uuid := resolveTaskRef(args[0])
 
 			if *watch {
 				watchResult(client, uuid, *ugly)
@@ -40,6 +42,8 @@ If the task is complete, the result JSON is printed.`,
 				os.Exit(1)
 			}
 
+			updateHistoryStatus(uuid, status)
+
 			if status == model.TaskStatusCompleted {
 				watchResult(client, uuid, *ugly) // this will just print the result
 				return
@@ -0,0 +1,161 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	log "github.com/cohix/simplog"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	taask "github.com/taask/client-golang"
+	"github.com/taask/taask-server/model"
+	"github.com/taask/taaskctl/history"
+)
+
+func historyCmd(connect ClientFunc) *cobra.Command {
+	var status *string
+	var kind *string
+	var context *string
+	var limit *int
+	var refresh *bool
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "lists the tasks created by taaskctl.",
+		Long: `history lists the tasks recorded in the local submission history, most recent last.
+Tasks in the history can be referred to by other commands with a unique prefix of their UUID,
+with @last for the most recently created task, or with an alias set by 'taaskctl history alias'.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if *refresh {
+				client, err := connect()
+				if err != nil {
+					log.LogError(errors.Wrap(err, "unable to connect"))
+					return
+				}
+
+				if err := refreshHistory(client); err != nil {
+					log.LogError(errors.Wrap(err, "failed to refreshHistory"))
+					os.Exit(1)
+				}
+			}
+
+			entries, err := history.Load()
+			if err != nil {
+				log.LogError(errors.Wrap(err, "failed to Load history"))
+				os.Exit(1)
+			}
+
+			filtered := []history.Entry{}
+			for _, entry := range entries {
+				if (*status == "" || entry.Status == *status) && (*kind == "" || entry.Kind == *kind) && (*context == "" || entry.Context == *context) {
+					filtered = append(filtered, entry)
+				}
+			}
+
+			if *limit > 0 && len(filtered) > *limit {
+				filtered = filtered[len(filtered)-*limit:]
+			}
+
+			fmt.Printf("%-26s  %-12s  %-16s  %-9s  %-19s  %s\n", "UUID", "ALIAS", "KIND", "STATUS", "CREATED", "SOURCE")
+			for _, entry := range filtered {
+				fmt.Printf("%-26s  %-12s  %-16s  %-9s  %-19s  %s\n", entry.UUID, entry.Alias, entry.Kind, entry.Status, entry.Time.Format("2006-01-02 15:04:05"), entry.Source)
+			}
+		},
+	}
+
+	status = cmd.Flags().String("status", "", "only list tasks with this last known status.")
+	kind = cmd.Flags().String("kind", "", "only list tasks of this kind.")
+	context = cmd.Flags().String("context", "", "only list tasks created on this server.")
+	limit = cmd.Flags().Int("limit", 0, "only list the most recent n tasks.")
+	refresh = cmd.Flags().Bool("refresh", false, "fetch the current status of unfinished tasks from the server before listing.")
+
+	markFlagCompletion(cmd, "kind", completeKinds)
+	markFlagCompletion(cmd, "context", completeContexts)
+
+	cmd.AddCommand(historyAliasCmd())
+
+	return cmd
+}
+
+func historyAliasCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "alias [uuid | prefix | @last] [alias]",
+		Short: "names a task in the history so it can be referred to as @alias.",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := history.SetAlias(args[0], args[1]); err != nil {
+				log.LogError(errors.Wrap(err, "failed to SetAlias"))
+				os.Exit(1)
+			}
+
+			log.LogInfo(fmt.Sprintf("task %s can now be referred to as %s%s", args[0], history.RefPrefix, args[1]))
+		},
+	}
+}
+
+func refreshHistory(client *taask.Client) error {
+	entries, err := history.Load()
+	if err != nil {
+		return errors.Wrap(err, "failed to Load")
+	}
+
+	statuses := make(map[string]string)
+	for _, entry := range entries {
+		if entry.Status == model.TaskStatusCompleted {
+			continue // complete is the only status a task can never leave
+		}
+
+		status, err := client.GetTaskStatus(entry.UUID)
+		if err != nil {
+			log.LogWarn(errors.Wrap(err, fmt.Sprintf("failed to GetTaskStatus for %s", entry.UUID)).Error())
+			continue
+		}
+
+		statuses[entry.UUID] = status
+	}
+
+	return history.UpdateStatuses(statuses)
+}
+
+// resolveTaskRef turns a UUID, UUID prefix, or @alias into a task UUID, exiting if it can't
+func resolveTaskRef(ref string) string {
+	uuid, err := history.Resolve(ref)
+	if err != nil {
+		log.LogError(errors.Wrap(err, "failed to Resolve task"))
+		os.Exit(1)
+	}
+
+	return uuid
+}
+
+// specSource returns the absolute path of a spec file to record as a task's source, so that it still means something
+// from another directory. Specs read from stdin are recorded as -
+func specSource(path string) string {
+	if path == "-" {
+		return path
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		log.LogWarn(errors.Wrap(err, "failed to Abs, recording the spec path as given").Error())
+		return path
+	}
+
+	return absPath
+}
+
+func recordSubmission(context, uuid, source string, task taask.Task) {
+	entry := history.NewEntry(uuid, context, source, task)
+	entry.Status = model.TaskStatusWaiting
+
+	if err := history.Record(entry); err != nil {
+		log.LogWarn(errors.Wrap(err, "failed to Record task in history").Error())
+	}
+}
+
+func updateHistoryStatus(uuid, status string) {
+	if err := history.UpdateStatus(uuid, status); err != nil {
+		log.LogWarn(errors.Wrap(err, "failed to UpdateStatus in history").Error())
+	}
+}
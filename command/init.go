@@ -13,6 +13,8 @@ import (
 	taask "github.com/taask/client-golang"
 	"github.com/taask/client-golang/config"
 	sconfig "github.com/taask/taask-server/config"
+	"github.com/taask/taaskctl/credential"
+	yaml "gopkg.in/yaml.v2"
 )
 
 func initCmd() *cobra.Command {
@@ -20,7 +22,9 @@ func initCmd() *cobra.Command {
 		Use:   "init",
 		Short: "Generate configuration for taask-server",
 		Long: `Init generates the initial configuration needed 
-for taask-server to run, and generates the auth configuration that taaskctl needs to connect`,
+for taask-server to run, and generates the auth configuration that taaskctl needs to connect.
+The admin and partner passphrases are stored encrypted with a key in the client and server config dirs,
+and every file is written with mode 0600.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			createConfigDir(config.DefaultClientConfigDir())
 			createConfigDir(sconfig.DefaultServerConfigDir())
@@ -28,34 +32,45 @@ for taask-server to run, and generates the auth configuration that taaskctl need
 
 			adminGroupConfig := taask.GenerateAdminGroup()
 
-			if err := adminGroupConfig.WriteServerConfig(sconfig.ClientAuthConfigFilename); err != nil {
-				log.LogError(errors.Wrap(err, "failed to WriteServerConfig"))
+			if err := writeYAML(filepath.Join(sconfig.DefaultServerConfigDir(), sconfig.ClientAuthConfigFilename), adminGroupConfig.ClientAuthConfig); err != nil {
+				log.LogError(errors.Wrap(err, "failed to writeYAML"))
 				os.Exit(1)
 			}
 
 			filename := fmt.Sprintf("%s-auth.yaml", adminGroupConfig.MemberGroup.Name)
-			if err := adminGroupConfig.WriteYAML(filepath.Join(config.DefaultClientConfigDir(), filename)); err != nil {
-				log.LogError(errors.Wrap(err, "failed to WriteYAML"))
+			adminAuthPath := filepath.Join(config.DefaultClientConfigDir(), filename)
+
+			if err := writeAuthConfig(adminAuthPath, adminGroupConfig); err != nil {
+				log.LogError(errors.Wrap(err, "failed to writeAuthConfig"))
 				os.Exit(1)
 			}
 
 			defaultRunnerGroup := taask.GenerateDefaultRunnerGroup()
 
-			if err := defaultRunnerGroup.WriteServerConfig(sconfig.RunnerAuthConfigFilename); err != nil {
-				log.LogError(errors.Wrap(err, "failed to WriteServerConfig"))
+			if err := writeYAML(filepath.Join(sconfig.DefaultServerConfigDir(), sconfig.RunnerAuthConfigFilename), defaultRunnerGroup.ClientAuthConfig); err != nil {
+				log.LogError(errors.Wrap(err, "failed to writeYAML"))
 				os.Exit(1)
 			}
 
 			runnerFilename := fmt.Sprintf("%s-auth.yaml", defaultRunnerGroup.MemberGroup.Name)
-			if err := defaultRunnerGroup.WriteYAML(filepath.Join(DefaultRunnerConfigDir(), runnerFilename)); err != nil {
-				log.LogError(errors.Wrap(err, "failed to WriteYAML"))
+			if err := writeYAML(filepath.Join(DefaultRunnerConfigDir(), runnerFilename), defaultRunnerGroup); err != nil {
+				log.LogError(errors.Wrap(err, "failed to writeYAML"))
 				os.Exit(1)
 			}
 
 			partnerGroup := taask.GenerateDefaultPartnerGroup()
 
-			if err := partnerGroup.WriteYAML(filepath.Join(sconfig.DefaultServerConfigDir(), sconfig.PartnerAuthConfigFilename)); err != nil {
-				log.LogError(errors.Wrap(err, "failed to WriteYAML"))
+			// taask-server only reads the member group from the partner config, so it is written like the admin and runner
+			// configs above, and the passphrase is kept encrypted next to it for configuring partners
+			partnerAuthPath := filepath.Join(sconfig.DefaultServerConfigDir(), sconfig.PartnerAuthConfigFilename)
+
+			if err := credential.Store(partnerAuthPath, partnerGroup.Passphrase); err != nil {
+				log.LogError(errors.Wrap(err, "failed to Store passphrase"))
+				os.Exit(1)
+			}
+
+			if err := writeYAML(partnerAuthPath, partnerGroup.ClientAuthConfig); err != nil {
+				log.LogError(errors.Wrap(err, "failed to writeYAML"))
 				os.Exit(1)
 			}
 
@@ -68,6 +83,36 @@ func createConfigDir(path string) error {
 	return os.MkdirAll(path, 0700)
 }
 
+// writeAuthConfig stores the config's passphrase encrypted and writes the config without it
+func writeAuthConfig(path string, localAuth *config.LocalAuthConfig) error {
+	if err := credential.Store(path, localAuth.Passphrase); err != nil {
+		return errors.Wrap(err, "failed to Store passphrase")
+	}
+
+	withoutPassphrase := *localAuth
+	withoutPassphrase.Passphrase = ""
+
+	if err := writeYAML(path, &withoutPassphrase); err != nil {
+		return errors.Wrap(err, "failed to writeYAML")
+	}
+
+	return nil
+}
+
+// writeYAML writes a config file readable only by its owner, the equivalent WriteYAML funcs in client-golang and taask-server use 0666
+func writeYAML(path string, config interface{}) error {
+	rawYAML, err := yaml.Marshal(config)
+	if err != nil {
+		return errors.Wrap(err, "failed to yaml.Marshal")
+	}
+
+	if err := credential.WriteFile(path, rawYAML); err != nil {
+		return errors.Wrap(err, "failed to WriteFile")
+	}
+
+	return nil
+}
+
 // DefaultRunnerConfigDir returns ~/.taask/runner/config unless XDG_CONFIG_HOME is set
 // TODO: find a better place for this
 func DefaultRunnerConfigDir() string {
@@ -0,0 +1,138 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/cohix/simplog"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	taask "github.com/taask/client-golang"
+	"github.com/taask/taaskctl/mapjob"
+	"github.com/taask/taaskctl/readwrite"
+)
+
+func mapCmd(connect ClientFunc, context string) *cobra.Command {
+	var filename *string
+	var inputsPath *string
+	var field *string
+	var output *string
+	var format *string
+	var concurrency *int
+
+	cmd := &cobra.Command{
+		Use:   "map",
+		Short: "runs a task once for every line of an inputs file and collects the results.",
+		Long: `map reads a task spec and a JSONL inputs file, and creates one task per input line with the input set at --field (such as body.item).
+At most --concurrency tasks run at a time. The results are written to --output in input order as JSONL or CSV, with a row for each input that failed.
+Running map again with the same output skips the inputs that already succeeded.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := connect()
+			if err != nil {
+				log.LogError(errors.Wrap(err, "unable to connect"))
+				return
+			}
+
+			task, err := readwrite.ReadTaskSpecFile(*filename)
+			if err != nil {
+				log.LogError(errors.Wrap(err, "failed to ReadTaskSpecFile"))
+				os.Exit(1)
+			}
+
+			inputs, err := mapjob.ReadInputs(*inputsPath)
+			if err != nil {
+				log.LogError(errors.Wrap(err, "failed to ReadInputs"))
+				os.Exit(1)
+			}
+
+			outPath := *output
+			if outPath == "" {
+				outPath = strings.TrimSuffix(*inputsPath, ".jsonl") + ".results.jsonl"
+			}
+
+			outFormat := *format
+			if outFormat == "" {
+				outFormat = mapjob.FormatForPath(outPath)
+			} else if outFormat != mapjob.FormatJSONL && outFormat != mapjob.FormatCSV {
+				log.LogError(fmt.Errorf("unknown format %s, must be %s or %s", outFormat, mapjob.FormatJSONL, mapjob.FormatCSV))
+				os.Exit(1)
+			}
+
+			previous, err := mapjob.ReadRows(outPath, outFormat)
+			if err != nil {
+				log.LogError(errors.Wrap(err, "failed to ReadRows from previous output"))
+				os.Exit(1)
+			}
+
+			w, err := mapjob.NewRowWriter(outPath, outFormat)
+			if err != nil {
+				log.LogError(errors.Wrap(err, "failed to NewRowWriter"))
+				os.Exit(1)
+			}
+
+			source := specSource(*filename)
+
+			job := mapjob.Job{
+				Spec:        *task,
+				Field:       *field,
+				Inputs:      inputs,
+				Concurrency: *concurrency,
+				Previous:    previous,
+				OnSubmit: func(uuid string, spec taask.Task) {
+					recordSubmission(context, uuid, source, spec)
+				},
+			}
+
+			rows, err := mapjob.Run(client, job, w, printMapProgress)
+			fmt.Fprintln(os.Stderr)
+			w.Close()
+
+			if err != nil {
+				log.LogError(errors.Wrap(err, "failed to Run"))
+				os.Exit(1)
+			}
+
+			// rows are written as they finish, rewrite them in input order now that they're all done
+			if err := mapjob.WriteRows(outPath, outFormat, rows); err != nil {
+				log.LogError(errors.Wrap(err, "failed to WriteRows"))
+				os.Exit(1)
+			}
+
+			failed := 0
+			for _, row := range rows {
+				if row.Status != mapjob.RowStatusComplete {
+					failed++
+				}
+			}
+
+			log.LogInfo(fmt.Sprintf("results written to %s", outPath))
+
+			if failed > 0 {
+				log.LogError(fmt.Errorf("%d of %d inputs failed, run map again to retry them", failed, len(rows)))
+				os.Exit(1)
+			}
+		},
+	}
+
+	filename = cmd.Flags().StringP("filename", "f", "", "the task spec to run for each input.")
+	inputsPath = cmd.Flags().String("inputs", "", "a JSONL file with one input per line.")
+	field = cmd.Flags().String("field", "body.item", "the path in the task spec to set each input at.")
+	output = cmd.Flags().StringP("output", "o", "", "the file to write results to (defaults to the inputs file with a .results.jsonl extension).")
+	format = cmd.Flags().String("format", "", "the output format, jsonl or csv (defaults to the output file's extension).")
+	concurrency = cmd.Flags().Int("concurrency", 10, "the maximum number of tasks to run at once.")
+
+	cmd.MarkFlagRequired("filename")
+	cmd.MarkFlagRequired("inputs")
+
+	cmd.MarkFlagFilename("filename")
+	cmd.MarkFlagFilename("inputs")
+	cmd.MarkFlagFilename("output")
+
+	return cmd
+}
+
+func printMapProgress(p mapjob.Progress) {
+	done := p.Skipped + p.Completed + p.Failed
+	fmt.Fprintf(os.Stderr, "\r%d/%d done (%d complete, %d failed, %d skipped), %d running", done, p.Total, p.Completed, p.Failed, p.Skipped, p.Running)
+}
@@ -7,8 +7,9 @@ import (
 
 func rootCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "taaskctl",
-		Short: "Taask Core is an open source system for running arbitrary jobs on any infrastructure.",
+		Use:                    "taaskctl",
+		BashCompletionFunction: bashCompletionFunc,
+		Short:                  "Taask Core is an open source system for running arbitrary jobs on any infrastructure.",
 		Long: `A distributed task execution platform
 allowing developers to run intensive and long-running compute tasks
 on any infrastructure. Taask is cloud-independent, fully open source,
@@ -0,0 +1,131 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/cohix/simplog"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/taask/taaskctl/stats"
+)
+
+// countsNameWidth is the width of the first column of the tables of task counts printed by stats and top
+const countsNameWidth = 28
+
+func statsCmd() *cobra.Command {
+	var metricsURL *string
+	var watch *bool
+	var interval *time.Duration
+	var jsonOutput *bool
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "summarises the task counts reported by the server's metrics.",
+		Long: `stats scrapes the server's Prometheus metrics endpoint and prints the number of waiting, queued, running, retrying, completed and failed tasks,
+in total and for each kind and runner.
+With --watch, stats scrapes again every --interval and prints how each count changed.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			snapshot, err := stats.Scrape(*metricsURL)
+			if err != nil {
+				log.LogError(errors.Wrap(err, "failed to Scrape"))
+				os.Exit(1)
+			}
+
+			summary := stats.Summarize(snapshot)
+			printSummary(summary, *jsonOutput, false)
+
+			if !*watch {
+				return
+			}
+
+			for {
+				<-time.After(*interval)
+
+				snapshot, err := stats.Scrape(*metricsURL)
+				if err != nil {
+					log.LogWarn(errors.Wrap(err, "failed to Scrape").Error())
+					continue
+				}
+
+				next := stats.Summarize(snapshot)
+				printSummary(next.Delta(summary), *jsonOutput, true)
+
+				summary = next
+			}
+		},
+	}
+
+	metricsURL = cmd.Flags().String("metrics-url", stats.DefaultMetricsURL, "the URL of the server's Prometheus metrics endpoint.")
+	watch = cmd.Flags().Bool("watch", false, "keep scraping and print the change in each count.")
+	interval = cmd.Flags().Duration("interval", 5*time.Second, "how often to scrape when combined with --watch.")
+	jsonOutput = cmd.Flags().Bool("json", false, "print the summary as JSON, one object per line.")
+
+	return cmd
+}
+
+func printSummary(summary *stats.Summary, asJSON, isDelta bool) {
+	if asJSON {
+		out := struct {
+			*stats.Summary
+			Delta bool `json:"delta"`
+		}{summary, isDelta}
+
+		summaryJSON, err := json.Marshal(out)
+		if err != nil {
+			log.LogError(errors.Wrap(err, "failed to Marshal summary"))
+			os.Exit(1)
+		}
+
+		fmt.Println(string(summaryJSON))
+		return
+	}
+
+	format := "%10.0f"
+	title := fmt.Sprintf("tasks at %s", summary.Time.Format("15:04:05"))
+	if isDelta {
+		format = "%+10.0f"
+		title = fmt.Sprintf("change at %s", summary.Time.Format("15:04:05"))
+	}
+
+	fmt.Println(title)
+
+	fmt.Println(countsHeader("TOTAL"))
+	fmt.Println(countsRow("all", summary.Totals, format))
+	fmt.Println()
+
+	fmt.Println(countsHeader("KIND"))
+	for _, kind := range stats.SortedKeys(summary.Kinds) {
+		fmt.Println(countsRow(kind, summary.Kinds[kind], format))
+	}
+	fmt.Println()
+
+	fmt.Println(countsHeader("RUNNER"))
+	for _, runner := range stats.SortedKeys(summary.Runners) {
+		fmt.Println(countsRow(runner, summary.Runners[runner], format))
+	}
+	fmt.Println()
+}
+
+// countsHeader formats the header of a table of task counts, with a column for each status
+func countsHeader(name string) string {
+	header := fmt.Sprintf("%-*s", countsNameWidth, name)
+	for _, status := range stats.Statuses {
+		header += fmt.Sprintf("%10s", strings.ToUpper(status))
+	}
+
+	return header
+}
+
+// countsRow formats a row of a table of task counts, format is the verb used for each count
+func countsRow(name string, counts stats.Counts, format string) string {
+	row := fmt.Sprintf("%-*s", countsNameWidth, name)
+	for _, status := range stats.Statuses {
+		row += fmt.Sprintf(format, counts[status])
+	}
+
+	return row
+}
@@ -0,0 +1,281 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/cohix/simplog"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/taask/taaskctl/stats"
+)
+
+const (
+	clearScreen = "\033[H\033[2J"
+	invertText  = "\033[7m"
+	resetText   = "\033[0m"
+
+	// escapeTimeout is how long to wait for the rest of an escape sequence after ESC
+	escapeTimeout = 50 * time.Millisecond
+)
+
+type topState struct {
+	url      string
+	interval time.Duration
+	current  *stats.Snapshot
+	previous *stats.Snapshot
+	err      error
+	selected int
+	expanded bool
+}
+
+func topCmd() *cobra.Command {
+	var metricsURL *string
+	var interval *time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "top",
+		Short: "shows a live dashboard of a Taask installation.",
+		Long: `top scrapes the server's metrics endpoint every --interval and shows the number of tasks in each status for each kind,
+the rate at which tasks are completing and failing, and the load on each runner.
+Use the arrow keys (or j and k) to select a kind, enter to show the runners for that kind, and q to quit.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			restore, err := rawTerminal()
+			if err != nil {
+				log.LogWarn(errors.Wrap(err, "failed to rawTerminal, keyboard navigation will not be available").Error())
+			}
+
+			keys := make(chan byte)
+			go readKeys(keys)
+
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+			state := &topState{
+				url:      *metricsURL,
+				interval: *interval,
+			}
+
+			state.refresh()
+			fmt.Print(state.render())
+
+			ticker := time.NewTicker(*interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					state.refresh()
+				case key := <-keys:
+					if !state.handleKey(key, keys) {
+						restore()
+						return
+					}
+				case <-signals:
+					restore()
+					return
+				}
+
+				fmt.Print(state.render())
+			}
+		},
+	}
+
+	metricsURL = cmd.Flags().String("metrics-url", stats.DefaultMetricsURL, "the URL of the server's Prometheus metrics endpoint.")
+	interval = cmd.Flags().Duration("interval", time.Second, "how often to refresh.")
+
+	return cmd
+}
+
+func (t *topState) refresh() {
+	snapshot, err := stats.Scrape(t.url)
+	if err != nil {
+		t.err = err
+		return
+	}
+
+	t.err = nil
+	t.previous = t.current
+	t.current = snapshot
+}
+
+// handleKey updates the selection for a key press, returning false if top should exit
+func (t *topState) handleKey(key byte, keys chan byte) bool {
+	switch key {
+	case 'q', 'Q':
+		return false
+	case 'j':
+		t.selected++
+	case 'k':
+		t.selected--
+	case '\r', '\n':
+		t.expanded = !t.expanded
+	case 27: // arrow keys arrive as ESC [ A (up) or ESC [ B (down)
+		if key, ok := escapeKey(keys); !ok || key != '[' {
+			break
+		}
+
+		key, _ := escapeKey(keys)
+		switch key {
+		case 'A':
+			t.selected--
+		case 'B':
+			t.selected++
+		}
+	}
+
+	if t.selected < 0 {
+		t.selected = 0
+	}
+
+	return true
+}
+
+// escapeKey reads the next byte of an escape sequence, returning false if none arrives soon after,
+// so that pressing Esc on its own doesn't stop top from refreshing or handling signals
+func escapeKey(keys chan byte) (byte, bool) {
+	select {
+	case key := <-keys:
+		return key, true
+	case <-time.After(escapeTimeout):
+		return 0, false
+	}
+}
+
+func (t *topState) render() string {
+	buf := &bytes.Buffer{}
+
+	buf.WriteString(clearScreen)
+	fmt.Fprintf(buf, "taaskctl top - %s - every %s    q: quit  up/down: select kind  enter: runners for kind\n\n", t.url, t.interval)
+
+	if t.err != nil {
+		fmt.Fprintf(buf, "(E) %s\n\n", t.err.Error())
+	}
+
+	if t.current == nil {
+		buf.WriteString("waiting for metrics...\n")
+		return buf.String()
+	}
+
+	fmt.Fprintf(buf, "refreshed %s\n\n", t.current.Time.Format("15:04:05"))
+
+	// totals and throughput
+	buf.WriteString(countsHeader("TASKS") + "\n")
+	buf.WriteString(countsRow("all kinds", t.current.Totals(), "%10.0f") + "\n\n")
+
+	if t.previous != nil {
+		elapsed := t.current.Time.Sub(t.previous.Time).Seconds()
+
+		completeRate := (t.current.Total("complete") - t.previous.Total("complete")) / elapsed
+		failedRate := (t.current.Total("failed") - t.previous.Total("failed")) / elapsed
+
+		fmt.Fprintf(buf, "THROUGHPUT  %.1f complete/s  %.1f failed/s\n\n", completeRate, failedRate)
+	}
+
+	// queue depth by kind
+	byKind := t.current.ByKind()
+	kinds := stats.SortedKeys(byKind)
+
+	if t.selected >= len(kinds) {
+		t.selected = len(kinds) - 1
+	}
+
+	var prevByKind map[string]stats.Counts
+	if t.previous != nil {
+		prevByKind = t.previous.ByKind()
+	}
+
+	fmt.Fprintf(buf, "%s%12s\n", countsHeader("KIND"), "NEW FAILED")
+
+	for i, kind := range kinds {
+		line := countsRow(kind, byKind[kind], "%10.0f")
+
+		newFailed := 0.0
+		if prev, ok := prevByKind[kind]; ok {
+			newFailed = byKind[kind]["failed"] - prev["failed"]
+		}
+		line += fmt.Sprintf("%12.0f", newFailed)
+
+		if i == t.selected {
+			line = invertText + line + resetText
+		}
+
+		buf.WriteString(line + "\n")
+	}
+
+	buf.WriteString("\n")
+
+	// runner load
+	byRunner := t.current.ByRunner()
+	runnerKinds := t.current.RunnerKinds()
+
+	title := "RUNNERS"
+	if t.expanded && t.selected >= 0 && t.selected < len(kinds) {
+		title = fmt.Sprintf("RUNNERS FOR %s", kinds[t.selected])
+	}
+
+	fmt.Fprintf(buf, "%-28s  %-24s%10s%10s%10s\n", title, "KIND", "QUEUED", "RUNNING", "LOAD")
+
+	for _, runner := range stats.SortedKeys(byRunner) {
+		if t.expanded && t.selected >= 0 && t.selected < len(kinds) && runnerKinds[runner] != kinds[t.selected] {
+			continue
+		}
+
+		queued := byRunner[runner]["queued"]
+		running := byRunner[runner]["running"]
+
+		fmt.Fprintf(buf, "%-28s  %-24s%10.0f%10.0f%10.0f\n", runner, runnerKinds[runner], queued, running, queued+running)
+	}
+
+	return buf.String()
+}
+
+// rawTerminal switches the terminal to read single key presses without echoing them, and returns a func to restore it
+func rawTerminal() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return func() {}, errors.Wrap(err, "failed to save terminal state")
+	}
+
+	if _, err := stty("cbreak", "-echo"); err != nil {
+		return func() {}, errors.Wrap(err, "failed to set cbreak")
+	}
+
+	return func() {
+		stty(strings.TrimSpace(saved))
+		fmt.Print(clearScreen)
+	}, nil
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to run stty")
+	}
+
+	return string(out), nil
+}
+
+func readKeys(keys chan byte) {
+	buf := make([]byte, 1)
+
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+
+		if n == 1 {
+			keys <- buf[0]
+		}
+	}
+}
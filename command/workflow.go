@@ -0,0 +1,131 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	log "github.com/cohix/simplog"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	taask "github.com/taask/client-golang"
+	"github.com/taask/taaskctl/workflow"
+)
+
+func workflowCmd(connect ClientFunc, context string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "workflow",
+		Short: "runs and inspects workflows made of dependent tasks.",
+		Long: `workflow runs a set of named task steps described in a workflow file.
+Steps can depend on other steps with dependsOn, and can use the results of
+the steps they depend on in their body with templates such as {{ steps.fetch.result.path }}.`,
+	}
+
+	cmd.AddCommand(workflowRunCmd(connect, context))
+	cmd.AddCommand(workflowStatusCmd())
+
+	return cmd
+}
+
+func workflowRunCmd(connect ClientFunc, context string) *cobra.Command {
+	var resume *bool
+
+	cmd := &cobra.Command{
+		Use:   "run [filename]",
+		Short: "runs the workflow described in a file.",
+		Long: `run reads the workflow file, creates a task for each step once the steps it depends on have completed,
+and runs independent steps in parallel. Steps that depend on a failed step are skipped.
+Progress is recorded so that a partially finished workflow can be continued with --resume.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := connect()
+			if err != nil {
+				log.LogError(errors.Wrap(err, "unable to connect"))
+				return
+			}
+
+			wf, err := workflow.ReadWorkflowFile(args[0])
+			if err != nil {
+				log.LogError(errors.Wrap(err, "failed to ReadWorkflowFile"))
+				os.Exit(1)
+			}
+
+			path, err := filepath.Abs(args[0])
+			if err != nil {
+				log.LogError(errors.Wrap(err, "failed to Abs"))
+				os.Exit(1)
+			}
+
+			state := workflow.NewState(wf, path)
+
+			if *resume {
+				state, err = workflow.ReadState(wf.Name)
+				if err != nil {
+					log.LogError(errors.Wrap(err, "failed to ReadState"))
+					os.Exit(1)
+				}
+
+				state.Merge(wf)
+			}
+
+			onSubmit := func(step, uuid string, spec taask.Task) {
+				recordSubmission(context, uuid, fmt.Sprintf("%s#%s", path, step), spec)
+			}
+
+			if err := workflow.Run(client, wf, state, onSubmit); err != nil {
+				log.LogError(errors.Wrap(err, fmt.Sprintf("workflow %s failed", wf.Name)))
+				log.LogInfo(fmt.Sprintf("fix the failing steps and continue with 'taaskctl workflow run %s --resume'", args[0]))
+				os.Exit(1)
+			}
+
+			log.LogInfo(fmt.Sprintf("workflow %s complete", wf.Name))
+		},
+	}
+
+	resume = cmd.Flags().Bool("resume", false, "continue a previous run of the workflow, skipping steps that already completed.")
+
+	return cmd
+}
+
+func workflowStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status [workflow name]",
+		Short: "prints the status of each step of a workflow run.",
+		Long: `status prints the recorded status, task UUID, and any error for each step of the most recent run of a workflow.
+The workflow name is the name set in the workflow file, or the file name without its extension.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			state, err := workflow.ReadState(args[0])
+			if err != nil {
+				log.LogError(errors.Wrap(err, "failed to ReadState"))
+				os.Exit(1)
+			}
+
+			wf, err := workflow.ReadWorkflowFile(state.File)
+			if err != nil {
+				log.LogWarn(errors.Wrap(err, "failed to ReadWorkflowFile, steps will be listed unordered").Error())
+
+				for name, step := range state.Steps {
+					printStepState(name, step)
+				}
+
+				return
+			}
+
+			for _, s := range wf.Steps {
+				if step, ok := state.Steps[s.Name]; ok {
+					printStepState(s.Name, step)
+				}
+			}
+		},
+	}
+}
+
+func printStepState(name string, step *workflow.StepState) {
+	line := fmt.Sprintf("%-20s %-10s %s", name, step.Status, step.UUID)
+	if step.Error != "" {
+		line = fmt.Sprintf("%s (%s)", line, step.Error)
+	}
+
+	fmt.Println(line)
+}
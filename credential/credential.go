@@ -0,0 +1,280 @@
+package credential
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cohix/simplcrypto"
+	"github.com/pkg/errors"
+	"github.com/taask/client-golang/config"
+)
+
+// PassphraseEnv and others are the environment variables that provide a group's passphrase
+const (
+	// PassphraseEnv holds the passphrase itself
+	PassphraseEnv = "TAASK_PASSPHRASE"
+
+	// PassphraseFDEnv holds the number of an open file descriptor to read the passphrase from
+	PassphraseFDEnv = "TAASK_PASSPHRASE_FD"
+
+	// CredentialHelperEnv holds a shell command that prints the passphrase, the group name is passed to it as $1
+	CredentialHelperEnv = "TAASK_CREDENTIAL_HELPER"
+)
+
+// LocalKeyFilename and others are consts for storing passphrases at rest
+const (
+	LocalKeyFilename    = "local.key"
+	PassphraseExtension = ".passphrase"
+
+	// FileMode is the mode used for every file containing credentials
+	FileMode os.FileMode = 0600
+)
+
+// Passphrase finds the passphrase for the group in the auth config at authPath. It is read from the first of:
+// TAASK_PASSPHRASE, the file descriptor in TAASK_PASSPHRASE_FD, the output of TAASK_CREDENTIAL_HELPER,
+// the encrypted passphrase stored next to the auth config, the auth config itself, or a prompt if stdin is a terminal. It returns an error if none of them provide one.
+func Passphrase(authPath string, localAuth *config.LocalAuthConfig) (string, error) {
+	if passphrase, ok := os.LookupEnv(PassphraseEnv); ok {
+		if passphrase == "" {
+			return "", fmt.Errorf("%s is set but empty", PassphraseEnv)
+		}
+
+		return passphrase, nil
+	}
+
+	if fdString, ok := os.LookupEnv(PassphraseFDEnv); ok {
+		passphrase, err := readFD(fdString)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to readFD")
+		} else if passphrase == "" {
+			return "", fmt.Errorf("read an empty passphrase from %s", PassphraseFDEnv)
+		}
+
+		return passphrase, nil
+	}
+
+	if helper, ok := os.LookupEnv(CredentialHelperEnv); ok {
+		passphrase, err := runHelper(helper, localAuth.MemberGroup.Name)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to runHelper")
+		} else if passphrase == "" {
+			return "", fmt.Errorf("%s %s returned an empty passphrase", CredentialHelperEnv, helper)
+		}
+
+		return passphrase, nil
+	}
+
+	passphrase, err := Load(authPath)
+	if err == nil {
+		return passphrase, nil
+	} else if !os.IsNotExist(errors.Cause(err)) {
+		return "", errors.Wrap(err, "failed to Load")
+	}
+
+	if localAuth.Passphrase != "" {
+		return localAuth.Passphrase, nil
+	}
+
+	if isTerminal(os.Stdin) {
+		passphrase, err := prompt(fmt.Sprintf("passphrase for group %s: ", localAuth.MemberGroup.Name))
+		if err != nil {
+			return "", errors.Wrap(err, "failed to prompt")
+		} else if passphrase == "" {
+			return "", errors.New("passphrase must not be empty")
+		}
+
+		return passphrase, nil
+	}
+
+	// the server only checks the group's auth hash, so an empty passphrase would connect and encrypt tasks under the wrong key
+	return "", fmt.Errorf("no passphrase found for group %s, set %s, %s or %s, or run taaskctl in a terminal to be prompted", localAuth.MemberGroup.Name, PassphraseEnv, PassphraseFDEnv, CredentialHelperEnv)
+}
+
+// Store encrypts passphrase with the local key (generating it if needed) and writes it next to the auth config at authPath
+func Store(authPath, passphrase string) error {
+	key, err := localKey(filepath.Dir(authPath), true)
+	if err != nil {
+		return errors.Wrap(err, "failed to localKey")
+	}
+
+	encPassphrase, err := key.Encrypt([]byte(passphrase))
+	if err != nil {
+		return errors.Wrap(err, "failed to Encrypt")
+	}
+
+	encJSON, err := encPassphrase.ToJSON()
+	if err != nil {
+		return errors.Wrap(err, "failed to ToJSON")
+	}
+
+	if err := WriteFile(passphrasePath(authPath), encJSON); err != nil {
+		return errors.Wrap(err, "failed to WriteFile")
+	}
+
+	return nil
+}
+
+// Load reads and decrypts the passphrase stored next to the auth config at authPath
+func Load(authPath string) (string, error) {
+	encJSON, err := ioutil.ReadFile(passphrasePath(authPath))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to ReadFile")
+	}
+
+	encPassphrase, err := simplcrypto.MessageFromJSON(encJSON)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to MessageFromJSON")
+	}
+
+	key, err := localKey(filepath.Dir(authPath), false)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to localKey")
+	}
+
+	passphrase, err := key.Decrypt(encPassphrase)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to Decrypt")
+	}
+
+	return string(passphrase), nil
+}
+
+// CheckMode returns an error if the file at path exists and can be read or written by anyone but its owner
+func CheckMode(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return errors.Wrap(err, "failed to Stat")
+	}
+
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%s has mode %s and can be read by other users, run 'chmod 600 %s'", path, info.Mode().Perm(), path)
+	}
+
+	return nil
+}
+
+// WriteFile writes data to path with FileMode, tightening the mode of an existing file
+func WriteFile(path string, data []byte) error {
+	if err := ioutil.WriteFile(path, data, FileMode); err != nil {
+		return errors.Wrap(err, "failed to WriteFile")
+	}
+
+	// WriteFile doesn't change the mode of a file that already exists
+	if err := os.Chmod(path, FileMode); err != nil {
+		return errors.Wrap(err, "failed to Chmod")
+	}
+
+	return nil
+}
+
+func passphrasePath(authPath string) string {
+	return strings.TrimSuffix(authPath, filepath.Ext(authPath)) + PassphraseExtension
+}
+
+func localKey(dir string, create bool) (*simplcrypto.SymKey, error) {
+	path := filepath.Join(dir, LocalKeyFilename)
+
+	keyJSON, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) || !create {
+			return nil, errors.Wrap(err, "failed to ReadFile")
+		}
+
+		key, err := simplcrypto.GenerateSymKey()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to GenerateSymKey")
+		}
+
+		if err := WriteFile(path, key.JSON()); err != nil {
+			return nil, errors.Wrap(err, "failed to WriteFile")
+		}
+
+		return key, nil
+	}
+
+	if err := CheckMode(path); err != nil {
+		return nil, errors.Wrap(err, "refusing to use local key")
+	}
+
+	key, err := simplcrypto.SymKeyFromJSON(keyJSON)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to SymKeyFromJSON")
+	}
+
+	return key, nil
+}
+
+func readFD(fdString string) (string, error) {
+	fd, err := strconv.Atoi(fdString)
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("%s is not a file descriptor", PassphraseFDEnv))
+	}
+
+	file := os.NewFile(uintptr(fd), "passphrase")
+	if file == nil {
+		return "", fmt.Errorf("file descriptor %d is not valid", fd)
+	}
+	defer file.Close()
+
+	raw, err := ioutil.ReadAll(file)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to ReadAll")
+	}
+
+	return strings.TrimRight(string(raw), "\r\n"), nil
+}
+
+func runHelper(helper, groupName string) (string, error) {
+	cmd := exec.Command("sh", "-c", helper, "taask-credential-helper", groupName)
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to run credential helper")
+	}
+
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func prompt(msg string) (string, error) {
+	fmt.Fprint(os.Stderr, msg)
+
+	// turn off echo while the passphrase is typed, if stty isn't available the passphrase is echoed
+	stty := exec.Command("stty", "-echo")
+	stty.Stdin = os.Stdin
+	if err := stty.Run(); err == nil {
+		defer func() {
+			restore := exec.Command("stty", "echo")
+			restore.Stdin = os.Stdin
+			restore.Run()
+
+			fmt.Fprintln(os.Stderr)
+		}()
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", errors.Wrap(err, "failed to ReadString")
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
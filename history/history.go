@@ -0,0 +1,324 @@
+package history
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/cohix/simplog"
+	"github.com/pkg/errors"
+	taask "github.com/taask/client-golang"
+	"github.com/taask/client-golang/config"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// HistoryFilename and others are consts for the local submission history
+const (
+	HistoryFilename = "history.jsonl"
+	lockExtension   = ".lock"
+
+	// RefPrefix marks a reference to a history entry by alias (or @last for the most recent submission)
+	RefPrefix = "@"
+	RefLast   = "@last"
+
+	minPrefixLength = 4
+)
+
+// Entry records a single task submission
+type Entry struct {
+	UUID     string
+	Alias    string `json:",omitempty"`
+	Context  string
+	Source   string
+	SpecHash string
+	Kind     string
+	Time     time.Time
+	Status   string
+}
+
+// Path returns the path of the history file
+func Path() string {
+	return filepath.Join(config.DefaultClientConfigDir(), HistoryFilename)
+}
+
+// NewEntry creates an entry for a task that was just created from source (a file path, - for stdin, or a command name)
+func NewEntry(uuid, context, source string, task taask.Task) Entry {
+	return Entry{
+		UUID:     uuid,
+		Context:  context,
+		Source:   source,
+		SpecHash: SpecHash(task),
+		Kind:     task.Kind,
+		Time:     time.Now(),
+	}
+}
+
+// SpecHash returns the SHA-256 of a task spec, which can be used to find tasks created from the same spec
+func SpecHash(task taask.Task) string {
+	// yaml (unlike json) can marshal the interface-keyed maps that yaml specs decode into, and sorts map keys
+	raw, err := yaml.Marshal(task)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(raw)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// update is appended to the history file to change an existing entry, so that the file never has to be rewritten
+type update struct {
+	Update string // the UUID of the entry to change
+	Status string `json:",omitempty"`
+	Alias  string `json:",omitempty"`
+}
+
+// record is a line of the history file, which is either an entry or an update to one
+type record struct {
+	Entry
+	Update string `json:",omitempty"`
+}
+
+// Record appends an entry to the history file
+func Record(entry Entry) error {
+	unlock, err := lock()
+	if err != nil {
+		return errors.Wrap(err, "failed to lock")
+	}
+	defer unlock()
+
+	return appendRecords(entry)
+}
+
+// Load reads all history entries, oldest first
+func Load() ([]Entry, error) {
+	unlock, err := lock()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to lock")
+	}
+	defer unlock()
+
+	return load()
+}
+
+// load reads the entries in the history file and applies the updates that follow them
+func load() ([]Entry, error) {
+	file, err := os.Open(Path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Entry{}, nil
+		}
+
+		return nil, errors.Wrap(err, "failed to Open")
+	}
+	defer file.Close()
+
+	entries := []Entry{}
+	indexes := make(map[string]int)
+
+	lineNumber := 0
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		// the history is only a convenience, so a bad line (such as one cut off by a full disk) is skipped rather than making it unusable
+		rec := record{}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			log.LogWarn(errors.Wrap(err, fmt.Sprintf("skipping line %d of %s", lineNumber, Path())).Error())
+			continue
+		}
+
+		if rec.Update == "" {
+			indexes[rec.UUID] = len(entries)
+			entries = append(entries, rec.Entry)
+			continue
+		}
+
+		i, ok := indexes[rec.Update]
+		if !ok {
+			continue
+		}
+
+		if rec.Status != "" {
+			entries[i].Status = rec.Status
+		}
+
+		if rec.Alias != "" {
+			// aliases are unique, so move it
+			for j := range entries {
+				if entries[j].Alias == rec.Alias {
+					entries[j].Alias = ""
+				}
+			}
+
+			entries[i].Alias = rec.Alias
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to Scan")
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+
+	return entries, nil
+}
+
+// appendRecords appends entries or updates to the history file, the caller must hold the lock
+func appendRecords(records ...interface{}) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	raw := []byte{}
+	for _, rec := range records {
+		recJSON, err := json.Marshal(rec)
+		if err != nil {
+			return errors.Wrap(err, "failed to Marshal")
+		}
+
+		raw = append(raw, recJSON...)
+		raw = append(raw, '\n')
+	}
+
+	file, err := os.OpenFile(Path(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to OpenFile")
+	}
+	defer file.Close()
+
+	if _, err := file.Write(raw); err != nil {
+		return errors.Wrap(err, "failed to Write")
+	}
+
+	return nil
+}
+
+// UpdateStatus sets the last known status of a task, doing nothing if the task isn't in the history
+func UpdateStatus(uuid, status string) error {
+	return UpdateStatuses(map[string]string{uuid: status})
+}
+
+// UpdateStatuses sets the last known status of each task in statuses (keyed by UUID) that is in the history
+func UpdateStatuses(statuses map[string]string) error {
+	unlock, err := lock()
+	if err != nil {
+		return errors.Wrap(err, "failed to lock")
+	}
+	defer unlock()
+
+	entries, err := load()
+	if err != nil {
+		return errors.Wrap(err, "failed to load")
+	}
+
+	updates := []interface{}{}
+	for _, entry := range entries {
+		if status, ok := statuses[entry.UUID]; ok && status != entry.Status {
+			updates = append(updates, update{Update: entry.UUID, Status: status})
+		}
+	}
+
+	return appendRecords(updates...)
+}
+
+// SetAlias gives the task referred to by ref an alias, which can then be used to refer to it as @alias
+func SetAlias(ref, alias string) error {
+	if alias == "" || strings.HasPrefix(alias, RefPrefix) || RefPrefix+alias == RefLast {
+		return fmt.Errorf("%s is not a valid alias", alias)
+	}
+
+	uuid, err := Resolve(ref)
+	if err != nil {
+		return errors.Wrap(err, "failed to Resolve")
+	}
+
+	unlock, err := lock()
+	if err != nil {
+		return errors.Wrap(err, "failed to lock")
+	}
+	defer unlock()
+
+	entries, err := load()
+	if err != nil {
+		return errors.Wrap(err, "failed to load")
+	}
+
+	for _, entry := range entries {
+		if entry.UUID == uuid {
+			return appendRecords(update{Update: uuid, Alias: alias})
+		}
+	}
+
+	return fmt.Errorf("task %s is not in the history", uuid)
+}
+
+// Resolve turns a reference into a task UUID. A reference can be a full UUID, a unique prefix of a UUID in the history,
+// @last for the most recent submission, or @alias for an entry given an alias
+func Resolve(ref string) (string, error) {
+	entries, err := Load()
+	if err != nil {
+		if strings.HasPrefix(ref, RefPrefix) {
+			return "", errors.Wrap(err, "failed to Load")
+		}
+
+		// the ref may still be a full UUID, so let the server decide if it exists
+		log.LogWarn(errors.Wrap(err, "failed to Load history, using the task reference as given").Error())
+		return ref, nil
+	}
+
+	if strings.HasPrefix(ref, RefPrefix) {
+		if len(entries) == 0 {
+			return "", fmt.Errorf("cannot resolve %s, history is empty", ref)
+		}
+
+		if ref == RefLast {
+			return entries[len(entries)-1].UUID, nil
+		}
+
+		alias := strings.TrimPrefix(ref, RefPrefix)
+		for _, entry := range entries {
+			if entry.Alias == alias {
+				return entry.UUID, nil
+			}
+		}
+
+		return "", fmt.Errorf("no task in history has alias %s", alias)
+	}
+
+	matches := []string{}
+	for _, entry := range entries {
+		if entry.UUID == ref {
+			return ref, nil
+		}
+
+		if len(ref) >= minPrefixLength && strings.HasPrefix(entry.UUID, ref) {
+			matches = append(matches, entry.UUID)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		// not in the history (or too short to be a prefix), let the server decide if it exists
+		return ref, nil
+	case 1:
+		return matches[0], nil
+	}
+
+	return "", fmt.Errorf("%s is ambiguous, it matches %s", ref, strings.Join(matches, ", "))
+}
@@ -0,0 +1,34 @@
+//go:build !windows
+// +build !windows
+
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// lock takes an exclusive lock on the history, which is shared by every taaskctl process, and returns a func to release it
+func lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(Path()), 0700); err != nil {
+		return nil, errors.Wrap(err, "failed to MkdirAll")
+	}
+
+	file, err := os.OpenFile(Path()+lockExtension, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to OpenFile")
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+		return nil, errors.Wrap(err, "failed to Flock")
+	}
+
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,23 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var historyLock = &sync.Mutex{}
+
+// lock only locks the history within this process, since there is no flock on windows. Entries are appended
+// with a single write, so concurrent taaskctl processes don't overwrite each other's changes
+func lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(Path()), 0700); err != nil {
+		return nil, errors.Wrap(err, "failed to MkdirAll")
+	}
+
+	historyLock.Lock()
+
+	return historyLock.Unlock, nil
+}
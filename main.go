@@ -1,24 +1,25 @@
 package main
 
 import (
+	"fmt"
 	"os"
-	"path/filepath"
 
 	log "github.com/cohix/simplog"
 	"github.com/pkg/errors"
 	"github.com/taask/client-golang"
-	"github.com/taask/client-golang/config"
+	"github.com/taask/taaskctl/clientconfig"
 	"github.com/taask/taaskctl/command"
+	"github.com/taask/taaskctl/credential"
 )
 
-func main() {
-	client, err := createClient()
-	if err != nil {
-		// TODO: figure out a better place to create the client
-		// log.LogWarn(errors.Wrap(err, "failed to createClient").Error())
-	}
+const (
+	serverHost = "localhost"
+	serverPort = "30688"
+)
 
-	cmd := command.Build(client)
+func main() {
+	// the client is created by the commands that need it, so that commands like init work without a server
+	cmd := command.Build(createClient, fmt.Sprintf("%s:%s", serverHost, serverPort))
 
 	if err := cmd.Execute(); err != nil {
 		log.LogError(err)
@@ -27,16 +28,27 @@ func main() {
 }
 
 func createClient() (*taask.Client, error) {
-	authPath := filepath.Join(config.DefaultClientConfigDir(), "admin-auth.yaml") // TODO: allow switching between groups
+	authPath := clientconfig.DefaultAuthPath() // TODO: allow switching between groups
 
-	localAuthConfig, err := config.LocalAuthConfigFromFile(authPath)
+	if err := credential.CheckMode(authPath); err != nil {
+		log.LogWarn(err.Error())
+	}
+
+	localAuthConfig, err := clientconfig.Load(authPath)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to LocalAuthConfigFromFile")
+		return nil, errors.Wrap(err, "failed to Load config")
 	}
 
-	client, err := taask.NewClient("localhost", "30688", localAuthConfig)
+	passphrase, err := credential.Passphrase(authPath, localAuthConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to Passphrase")
+	}
+
+	localAuthConfig.Passphrase = passphrase
+
+	client, err := taask.NewClient(serverHost, serverPort, localAuthConfig)
 	if err != nil {
-		log.LogWarn(errors.Wrap(err, "failed to NewClient").Error())
+		return nil, errors.Wrap(err, "failed to NewClient")
 	}
 
 	return client, nil
@@ -0,0 +1,117 @@
+package mapjob
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	taask "github.com/taask/client-golang"
+	"github.com/taask/taaskctl/readwrite"
+)
+
+// Input is a single line of a map job's inputs file
+type Input struct {
+	Index int
+	Value interface{}
+	Raw   json.RawMessage
+}
+
+// ReadInputs reads a JSONL file, one input per non-empty line
+func ReadInputs(path string) ([]Input, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to Open")
+	}
+	defer file.Close()
+
+	inputs := []Input{}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		val, err := decodeInput(raw)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to decodeInput line %d", line))
+		}
+
+		// re-marshal so that inputs can be compared regardless of formatting
+		canonical, err := json.Marshal(val)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to Marshal line %d", line))
+		}
+
+		inputs = append(inputs, Input{Index: len(inputs), Value: val, Raw: canonical})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to Scan")
+	}
+
+	return inputs, nil
+}
+
+// decodeInput decodes a line of JSON, keeping numbers as json.Number so that large IDs aren't rounded to a float64
+func decodeInput(raw []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var val interface{}
+	if err := decoder.Decode(&val); err != nil {
+		return nil, errors.Wrap(err, "failed to Decode")
+	}
+
+	var extra interface{}
+	if err := decoder.Decode(&extra); err != io.EOF {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+
+	return val, nil
+}
+
+// Expand returns a copy of spec with the input value set at field, a dotted path starting with body (such as body.item)
+func Expand(spec taask.Task, field string, input Input) (taask.Task, error) {
+	path := strings.Split(field, ".")
+	if len(path) < 2 || path[0] != "body" {
+		return taask.Task{}, fmt.Errorf("field %s must be a path inside the task body, such as body.item", field)
+	}
+
+	body, _ := readwrite.NormalizeValue(spec.Body).(map[string]interface{})
+	if body == nil {
+		body = make(map[string]interface{})
+	}
+
+	obj := body
+	for _, key := range path[1 : len(path)-1] {
+		next, ok := obj[key].(map[string]interface{})
+		if !ok {
+			if _, exists := obj[key]; exists {
+				return taask.Task{}, fmt.Errorf("field %s cannot be set, %s is not an object", field, key)
+			}
+
+			next = make(map[string]interface{})
+			obj[key] = next
+		}
+
+		obj = next
+	}
+
+	obj[path[len(path)-1]] = input.Value
+
+	spec.Body = body
+
+	return spec, nil
+}
@@ -0,0 +1,176 @@
+package mapjob
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	taask "github.com/taask/client-golang"
+)
+
+func TestReadInputs(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		wantRaw  []string
+		wantErr  bool
+	}{
+		{
+			name:     "one input per line, skipping blank lines",
+			contents: "{\"a\": 1}\n\n  \"two\"  \n[1, 2]\n",
+			wantRaw:  []string{`{"a":1}`, `"two"`, `[1,2]`},
+		},
+		{
+			name:     "large numbers are kept exactly",
+			contents: `{"id": 12345678901234567891, "ratio": 0.10000000000000000001}`,
+			wantRaw:  []string{`{"id":12345678901234567891,"ratio":0.10000000000000000001}`},
+		},
+		{
+			name:     "invalid JSON",
+			contents: "{\"a\": \n",
+			wantErr:  true,
+		},
+		{
+			name:     "two values on one line",
+			contents: "{\"a\": 1} {\"b\": 2}\n",
+			wantErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "taaskctl-mapjob")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "inputs.jsonl")
+			if err := ioutil.WriteFile(path, []byte(test.contents), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			inputs, err := ReadInputs(path)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			raw := []string{}
+			for i, input := range inputs {
+				if input.Index != i {
+					t.Errorf("input %d has index %d", i, input.Index)
+				}
+
+				raw = append(raw, string(input.Raw))
+			}
+
+			if !reflect.DeepEqual(raw, test.wantRaw) {
+				t.Errorf("got %q, want %q", raw, test.wantRaw)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	input := Input{Value: map[string]interface{}{"id": json.Number("12345678901234567891")}}
+
+	tests := []struct {
+		name    string
+		body    map[string]interface{}
+		field   string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:  "top level field of an empty body",
+			field: "body.item",
+			want:  map[string]interface{}{"item": input.Value},
+		},
+		{
+			name:  "nested fields are created",
+			body:  map[string]interface{}{"keep": "me"},
+			field: "body.a.b.item",
+			want: map[string]interface{}{
+				"keep": "me",
+				"a":    map[string]interface{}{"b": map[string]interface{}{"item": input.Value}},
+			},
+		},
+		{
+			name:  "existing objects are kept",
+			body:  map[string]interface{}{"a": map[string]interface{}{"other": 1}},
+			field: "body.a.item",
+			want:  map[string]interface{}{"a": map[string]interface{}{"other": 1, "item": input.Value}},
+		},
+		{
+			name:  "yaml interface-keyed objects are converted",
+			body:  map[string]interface{}{"a": map[interface{}]interface{}{"other": 1}},
+			field: "body.a.item",
+			want:  map[string]interface{}{"a": map[string]interface{}{"other": 1, "item": input.Value}},
+		},
+		{
+			name:  "an existing value is replaced",
+			body:  map[string]interface{}{"item": "placeholder"},
+			field: "body.item",
+			want:  map[string]interface{}{"item": input.Value},
+		},
+		{
+			name:    "intermediate key that isn't an object",
+			body:    map[string]interface{}{"a": "string"},
+			field:   "body.a.item",
+			wantErr: true,
+		},
+		{
+			name:    "field outside the body",
+			field:   "meta.item",
+			wantErr: true,
+		},
+		{
+			name:    "the body itself",
+			field:   "body",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spec := taask.Task{Kind: "io.taask.k8s", Body: test.body}
+			before, _ := json.Marshal(spec.Body)
+
+			got, err := Expand(spec, test.field, input)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(got.Body, test.want) {
+				t.Errorf("got %#v, want %#v", got.Body, test.want)
+			}
+
+			if got.Kind != spec.Kind {
+				t.Errorf("kind changed to %s", got.Kind)
+			}
+
+			if after, _ := json.Marshal(spec.Body); string(after) != string(before) {
+				t.Errorf("the original body was modified: %s", after)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package mapjob
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FormatJSONL and others are the supported output formats
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+
+	RowStatusComplete = "complete"
+	RowStatusFailed   = "failed"
+)
+
+var csvHeader = []string{"index", "uuid", "status", "input", "result", "error"}
+
+// Row is the outcome of running the task for a single input
+type Row struct {
+	Index  int             `json:"index"`
+	UUID   string          `json:"uuid,omitempty"`
+	Status string          `json:"status"`
+	Input  json.RawMessage `json:"input"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// FormatForPath returns the output format implied by a file's extension
+func FormatForPath(path string) string {
+	if strings.ToLower(filepath.Ext(path)) == ".csv" {
+		return FormatCSV
+	}
+
+	return FormatJSONL
+}
+
+// ReadRows reads the rows of a previous run's output, returning no rows if the file does not exist
+func ReadRows(path, format string) ([]Row, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Row{}, nil
+		}
+
+		return nil, errors.Wrap(err, "failed to Open")
+	}
+	defer file.Close()
+
+	if format == FormatCSV {
+		return readCSVRows(file)
+	}
+
+	return readJSONLRows(file)
+}
+
+func readJSONLRows(r io.Reader) ([]Row, error) {
+	rows := []Row{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		row := Row{}
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			return nil, errors.Wrap(err, "failed to Unmarshal row")
+		}
+
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to Scan")
+	}
+
+	return rows, nil
+}
+
+func readCSVRows(r io.Reader) ([]Row, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to ReadAll")
+	}
+
+	rows := []Row{}
+
+	for i, record := range records {
+		if i == 0 && len(record) > 0 && record[0] == csvHeader[0] {
+			continue
+		}
+
+		if len(record) != len(csvHeader) {
+			return nil, fmt.Errorf("csv record %d has %d fields, expected %d", i, len(record), len(csvHeader))
+		}
+
+		index, err := strconv.Atoi(record[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to Atoi index")
+		}
+
+		row := Row{
+			Index:  index,
+			UUID:   record[1],
+			Status: record[2],
+			Input:  json.RawMessage(record[3]),
+			Error:  record[5],
+		}
+
+		if record[4] != "" {
+			row.Result = json.RawMessage(record[4])
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// RowWriter appends rows to an output file as they complete
+type RowWriter struct {
+	file      *os.File
+	format    string
+	csvWriter *csv.Writer
+}
+
+// NewRowWriter creates (or truncates) the file at path and writes rows to it in format
+func NewRowWriter(path, format string) (*RowWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to Create")
+	}
+
+	w := &RowWriter{
+		file:   file,
+		format: format,
+	}
+
+	if format == FormatCSV {
+		w.csvWriter = csv.NewWriter(file)
+
+		if err := w.csvWriter.Write(csvHeader); err != nil {
+			file.Close()
+			return nil, errors.Wrap(err, "failed to Write header")
+		}
+	}
+
+	return w, nil
+}
+
+// Write writes a row and flushes it to disk
+func (w *RowWriter) Write(row Row) error {
+	if w.format == FormatCSV {
+		record := []string{strconv.Itoa(row.Index), row.UUID, row.Status, string(row.Input), string(row.Result), row.Error}
+
+		if err := w.csvWriter.Write(record); err != nil {
+			return errors.Wrap(err, "failed to Write record")
+		}
+
+		w.csvWriter.Flush()
+
+		return errors.Wrap(w.csvWriter.Error(), "failed to Flush")
+	}
+
+	rowJSON, err := json.Marshal(row)
+	if err != nil {
+		return errors.Wrap(err, "failed to Marshal row")
+	}
+
+	if _, err := w.file.Write(append(rowJSON, '\n')); err != nil {
+		return errors.Wrap(err, "failed to Write row")
+	}
+
+	return nil
+}
+
+// Close closes the underlying file
+func (w *RowWriter) Close() error {
+	return w.file.Close()
+}
+
+// WriteRows writes rows to path in input order, replacing the file atomically
+func WriteRows(path, format string, rows []Row) error {
+	sorted := make([]Row, len(rows))
+	copy(sorted, rows)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Index < sorted[j].Index
+	})
+
+	tmpPath := path + ".tmp"
+
+	w, err := NewRowWriter(tmpPath, format)
+	if err != nil {
+		return errors.Wrap(err, "failed to NewRowWriter")
+	}
+
+	for _, row := range sorted {
+		if err := w.Write(row); err != nil {
+			w.Close()
+			return errors.Wrap(err, "failed to Write")
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "failed to Close")
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.Wrap(err, "failed to Rename")
+	}
+
+	return nil
+}
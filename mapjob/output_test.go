@@ -0,0 +1,72 @@
+package mapjob
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRowsRoundTrip(t *testing.T) {
+	rows := []Row{
+		{Index: 2, UUID: "uuid-2", Status: RowStatusFailed, Input: json.RawMessage(`"text, with \"quotes\""`), Error: "failed to SendSpecTask: boom,\nsecond line"},
+		{Index: 0, UUID: "uuid-0", Status: RowStatusComplete, Input: json.RawMessage(`{"id":12345678901234567891}`), Result: json.RawMessage(`{"answer":[1,2]}`)},
+		{Index: 1, Status: RowStatusFailed, Input: json.RawMessage(`[1,"a"]`), Error: "failed to Expand"},
+	}
+
+	// WriteRows sorts by index
+	want := []Row{rows[1], rows[2], rows[0]}
+
+	for _, format := range []string{FormatJSONL, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "taaskctl-mapjob")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "results."+format)
+
+			if err := WriteRows(path, format, rows); err != nil {
+				t.Fatalf("failed to WriteRows: %s", err)
+			}
+
+			got, err := ReadRows(path, format)
+			if err != nil {
+				t.Fatalf("failed to ReadRows: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestReadRowsMissingFile(t *testing.T) {
+	rows, err := ReadRows(filepath.Join(os.TempDir(), "taaskctl-mapjob-does-not-exist.jsonl"), FormatJSONL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(rows) != 0 {
+		t.Errorf("got %d rows", len(rows))
+	}
+}
+
+func TestFormatForPath(t *testing.T) {
+	tests := map[string]string{
+		"results.csv":   FormatCSV,
+		"RESULTS.CSV":   FormatCSV,
+		"results.jsonl": FormatJSONL,
+		"results":       FormatJSONL,
+	}
+
+	for path, want := range tests {
+		if got := FormatForPath(path); got != want {
+			t.Errorf("FormatForPath(%s) = %s, want %s", path, got, want)
+		}
+	}
+}
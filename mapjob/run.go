@@ -0,0 +1,171 @@
+package mapjob
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+
+	"github.com/pkg/errors"
+	taask "github.com/taask/client-golang"
+)
+
+// Job describes a spec to run once per input
+type Job struct {
+	Spec        taask.Task
+	Field       string
+	Inputs      []Input
+	Concurrency int
+
+	// Previous holds the rows of an earlier run, inputs that already succeeded are not run again
+	Previous []Row
+
+	// OnSubmit, if set, is called (concurrently) for every task created
+	OnSubmit func(uuid string, spec taask.Task)
+}
+
+// Progress counts the inputs of a job in each state
+type Progress struct {
+	Total     int
+	Skipped   int
+	Running   int
+	Completed int
+	Failed    int
+}
+
+// Run creates a task for every input of the job (at most Concurrency at a time), writes each row to w as it finishes,
+// and calls progressFunc whenever the progress changes. The returned rows include rows carried over from Previous.
+func Run(client *taask.Client, job Job, w *RowWriter, progressFunc func(Progress)) ([]Row, error) {
+	progress := Progress{Total: len(job.Inputs)}
+	progressLock := &sync.Mutex{}
+
+	updateProgress := func(update func(p *Progress)) {
+		progressLock.Lock()
+		defer progressLock.Unlock()
+
+		update(&progress)
+		progressFunc(progress)
+	}
+
+	rows, todo := splitPrevious(job.Inputs, job.Previous)
+
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			return nil, errors.Wrap(err, "failed to Write previous row")
+		}
+	}
+
+	updateProgress(func(p *Progress) { p.Skipped = len(rows) })
+
+	concurrency := job.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	rowChan := make(chan Row, concurrency)
+	sem := make(chan bool, concurrency)
+
+	go func() {
+		for _, input := range todo {
+			sem <- true
+
+			go func(input Input) {
+				defer func() { <-sem }()
+
+				rowChan <- runInput(client, job, input, func() {
+					updateProgress(func(p *Progress) { p.Running++ })
+				})
+			}(input)
+		}
+	}()
+
+	for range todo {
+		row := <-rowChan
+
+		if err := w.Write(row); err != nil {
+			return nil, errors.Wrap(err, "failed to Write row")
+		}
+
+		rows = append(rows, row)
+
+		updateProgress(func(p *Progress) {
+			if row.UUID != "" {
+				p.Running--
+			}
+
+			if row.Status == RowStatusComplete {
+				p.Completed++
+			} else {
+				p.Failed++
+			}
+		})
+	}
+
+	return rows, nil
+}
+
+// splitPrevious returns the previous rows that can be carried over, for inputs that succeeded before and haven't changed,
+// and the inputs that still need to be run
+func splitPrevious(inputs []Input, previous []Row) ([]Row, []Input) {
+	succeeded := make(map[int]Row)
+	for _, row := range previous {
+		if row.Status == RowStatusComplete {
+			succeeded[row.Index] = row
+		}
+	}
+
+	carried := []Row{}
+	todo := []Input{}
+
+	for _, input := range inputs {
+		if prev, ok := succeeded[input.Index]; ok && bytes.Equal(prev.Input, input.Raw) {
+			carried = append(carried, prev)
+			continue
+		}
+
+		todo = append(todo, input)
+	}
+
+	return carried, todo
+}
+
+func runInput(client *taask.Client, job Job, input Input, submitted func()) Row {
+	row := Row{
+		Index:  input.Index,
+		Status: RowStatusFailed,
+		Input:  input.Raw,
+	}
+
+	spec, err := Expand(job.Spec, job.Field, input)
+	if err != nil {
+		row.Error = errors.Wrap(err, "failed to Expand").Error()
+		return row
+	}
+
+	uuid, err := client.SendSpecTask(spec)
+	if err != nil {
+		row.Error = errors.Wrap(err, "failed to SendSpecTask").Error()
+		return row
+	}
+
+	row.UUID = uuid
+	if job.OnSubmit != nil {
+		job.OnSubmit(uuid, spec)
+	}
+	submitted()
+
+	result, err := client.StreamTaskResult(uuid)
+	if err != nil {
+		row.Error = errors.Wrap(err, "failed to StreamTaskResult").Error()
+		return row
+	}
+
+	if !json.Valid(result) {
+		// keep results that aren't JSON as a JSON string so every row stays valid JSON
+		result, _ = json.Marshal(string(result))
+	}
+
+	row.Status = RowStatusComplete
+	row.Result = result
+
+	return row
+}
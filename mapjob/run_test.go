@@ -0,0 +1,101 @@
+package mapjob
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSplitPrevious(t *testing.T) {
+	input := func(index int, raw string) Input {
+		return Input{Index: index, Raw: json.RawMessage(raw)}
+	}
+
+	row := func(index int, status, raw string) Row {
+		return Row{Index: index, UUID: "uuid", Status: status, Input: json.RawMessage(raw), Result: json.RawMessage(`"done"`)}
+	}
+
+	tests := []struct {
+		name        string
+		inputs      []Input
+		previous    []Row
+		wantCarried []int
+		wantTodo    []int
+	}{
+		{
+			name:     "no previous run",
+			inputs:   []Input{input(0, `1`), input(1, `2`)},
+			previous: []Row{},
+			wantTodo: []int{0, 1},
+		},
+		{
+			name:        "unchanged successes are carried over",
+			inputs:      []Input{input(0, `1`), input(1, `2`)},
+			previous:    []Row{row(0, RowStatusComplete, `1`), row(1, RowStatusComplete, `2`)},
+			wantCarried: []int{0, 1},
+		},
+		{
+			name:        "failures are run again",
+			inputs:      []Input{input(0, `1`), input(1, `2`)},
+			previous:    []Row{row(0, RowStatusComplete, `1`), row(1, RowStatusFailed, `2`)},
+			wantCarried: []int{0},
+			wantTodo:    []int{1},
+		},
+		{
+			name:        "a changed input line is run again",
+			inputs:      []Input{input(0, `1`), input(1, `{"id":3}`)},
+			previous:    []Row{row(0, RowStatusComplete, `1`), row(1, RowStatusComplete, `{"id":2}`)},
+			wantCarried: []int{0},
+			wantTodo:    []int{1},
+		},
+		{
+			name:        "inputs added since the previous run are run",
+			inputs:      []Input{input(0, `1`), input(1, `2`), input(2, `3`)},
+			previous:    []Row{row(0, RowStatusComplete, `1`), row(1, RowStatusComplete, `2`)},
+			wantCarried: []int{0, 1},
+			wantTodo:    []int{2},
+		},
+		{
+			name:     "an input moved to another line is run again",
+			inputs:   []Input{input(0, `2`), input(1, `1`)},
+			previous: []Row{row(0, RowStatusComplete, `1`), row(1, RowStatusComplete, `2`)},
+			wantTodo: []int{0, 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			carried, todo := splitPrevious(test.inputs, test.previous)
+
+			carriedIndexes := []int{}
+			for _, r := range carried {
+				carriedIndexes = append(carriedIndexes, r.Index)
+
+				if r.UUID == "" || string(r.Result) != `"done"` {
+					t.Errorf("carried row %d lost its UUID or result", r.Index)
+				}
+			}
+
+			todoIndexes := []int{}
+			for _, i := range todo {
+				todoIndexes = append(todoIndexes, i.Index)
+			}
+
+			if test.wantCarried == nil {
+				test.wantCarried = []int{}
+			}
+
+			if test.wantTodo == nil {
+				test.wantTodo = []int{}
+			}
+
+			if !reflect.DeepEqual(carriedIndexes, test.wantCarried) {
+				t.Errorf("carried %v, want %v", carriedIndexes, test.wantCarried)
+			}
+
+			if !reflect.DeepEqual(todoIndexes, test.wantTodo) {
+				t.Errorf("todo %v, want %v", todoIndexes, test.wantTodo)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package readwrite
+
+import "fmt"
+
+// NormalizeValue deep copies a decoded value, converting the interface-keyed maps that yaml decodes nested objects into
+// to the string-keyed maps produced by json, which can be marshalled to JSON and looked up by field name
+func NormalizeValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, inner := range v {
+			out[key] = NormalizeValue(inner)
+		}
+
+		return out
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, inner := range v {
+			out[fmt.Sprintf("%v", key)] = NormalizeValue(inner)
+		}
+
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, inner := range v {
+			out[i] = NormalizeValue(inner)
+		}
+
+		return out
+	}
+
+	return val
+}
@@ -0,0 +1,181 @@
+package stats
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// DefaultMetricsURL and others are consts for scraping taask-server's metrics
+const (
+	DefaultMetricsURL = "http://localhost:3689/metrics"
+
+	metricsPrefix = "taask_tasks_"
+	kindLabel     = "kind"
+	runnerLabel   = "runner"
+
+	scrapeTimeout = 5 * time.Second
+)
+
+// Statuses lists the task statuses exported by taask-server, in the order a task moves through them
+var Statuses = []string{"waiting", "queued", "running", "retrying", "complete", "failed"}
+
+// Series is the number of tasks with a status, of a kind, assigned to a runner
+type Series struct {
+	Status string
+	Kind   string
+	Runner string
+	Value  float64
+}
+
+// Snapshot is the state of a server's task metrics at a point in time
+type Snapshot struct {
+	Time   time.Time
+	Series []Series
+}
+
+// Scrape fetches and parses the Prometheus metrics exposed at url
+func Scrape(url string) (*Snapshot, error) {
+	client := &http.Client{Timeout: scrapeTimeout}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to Get")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metrics endpoint returned %s", resp.Status)
+	}
+
+	parser := &expfmt.TextParser{}
+
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to TextToMetricFamilies")
+	}
+
+	return snapshotFromFamilies(families), nil
+}
+
+func snapshotFromFamilies(families map[string]*dto.MetricFamily) *Snapshot {
+	snapshot := &Snapshot{
+		Time:   time.Now(),
+		Series: []Series{},
+	}
+
+	for name, family := range families {
+		if !strings.HasPrefix(name, metricsPrefix) {
+			continue
+		}
+
+		status := strings.TrimPrefix(name, metricsPrefix)
+
+		for _, metric := range family.GetMetric() {
+			series := Series{Status: status}
+
+			for _, label := range metric.GetLabel() {
+				switch label.GetName() {
+				case kindLabel:
+					series.Kind = label.GetValue()
+				case runnerLabel:
+					series.Runner = label.GetValue()
+				}
+			}
+
+			switch {
+			case metric.GetGauge() != nil:
+				series.Value = metric.GetGauge().GetValue()
+			case metric.GetCounter() != nil:
+				series.Value = metric.GetCounter().GetValue()
+			case metric.GetUntyped() != nil:
+				series.Value = metric.GetUntyped().GetValue()
+			}
+
+			snapshot.Series = append(snapshot.Series, series)
+		}
+	}
+
+	return snapshot
+}
+
+// Total returns the number of tasks with status
+func (s *Snapshot) Total(status string) float64 {
+	total := 0.0
+	for _, series := range s.Series {
+		if series.Status == status {
+			total += series.Value
+		}
+	}
+
+	return total
+}
+
+// Totals returns the number of tasks in each status
+func (s *Snapshot) Totals() Counts {
+	totals := Counts{}
+	for _, status := range Statuses {
+		totals[status] = s.Total(status)
+	}
+
+	return totals
+}
+
+// ByKind returns the number of tasks in each status for each kind
+func (s *Snapshot) ByKind() map[string]Counts {
+	return s.group(func(series Series) string { return series.Kind })
+}
+
+// ByRunner returns the number of tasks in each status for each runner, tasks that haven't been assigned a runner are not included
+func (s *Snapshot) ByRunner() map[string]Counts {
+	grouped := s.group(func(series Series) string { return series.Runner })
+	delete(grouped, "")
+
+	return grouped
+}
+
+// RunnerKinds returns the kind of each runner, based on the tasks assigned to it
+func (s *Snapshot) RunnerKinds() map[string]string {
+	kinds := make(map[string]string)
+	for _, series := range s.Series {
+		if series.Runner != "" {
+			kinds[series.Runner] = series.Kind
+		}
+	}
+
+	return kinds
+}
+
+func (s *Snapshot) group(key func(Series) string) map[string]Counts {
+	grouped := make(map[string]Counts)
+
+	for _, series := range s.Series {
+		k := key(series)
+
+		if _, ok := grouped[k]; !ok {
+			grouped[k] = Counts{}
+		}
+
+		grouped[k][series.Status] += series.Value
+	}
+
+	return grouped
+}
+
+// SortedKeys returns the kinds or runners of a grouped map in order
+func SortedKeys(grouped map[string]Counts) []string {
+	keys := []string{}
+	for k := range grouped {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
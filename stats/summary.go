@@ -0,0 +1,85 @@
+package stats
+
+import "time"
+
+// Counts is the number of tasks in each status
+type Counts map[string]float64
+
+// Summary is the number of tasks in each status, in total and for each kind and runner
+type Summary struct {
+	Time    time.Time         `json:"time"`
+	Totals  Counts            `json:"totals"`
+	Kinds   map[string]Counts `json:"kinds"`
+	Runners map[string]Counts `json:"runners"`
+}
+
+// Summarize totals a snapshot by kind and by runner
+func Summarize(s *Snapshot) *Summary {
+	summary := &Summary{
+		Time:    s.Time,
+		Totals:  s.Totals(),
+		Kinds:   map[string]Counts{},
+		Runners: map[string]Counts{},
+	}
+
+	for kind, counts := range s.ByKind() {
+		summary.Kinds[kind] = filterStatuses(counts)
+	}
+
+	for runner, counts := range s.ByRunner() {
+		summary.Runners[runner] = filterStatuses(counts)
+	}
+
+	return summary
+}
+
+// Delta returns the change in each count between prev and s
+func (s *Summary) Delta(prev *Summary) *Summary {
+	delta := &Summary{
+		Time:    s.Time,
+		Totals:  s.Totals.delta(prev.Totals),
+		Kinds:   map[string]Counts{},
+		Runners: map[string]Counts{},
+	}
+
+	for kind, counts := range s.Kinds {
+		delta.Kinds[kind] = counts.delta(prev.Kinds[kind])
+	}
+
+	for kind, counts := range prev.Kinds {
+		if _, ok := s.Kinds[kind]; !ok {
+			delta.Kinds[kind] = Counts{}.delta(counts)
+		}
+	}
+
+	for runner, counts := range s.Runners {
+		delta.Runners[runner] = counts.delta(prev.Runners[runner])
+	}
+
+	for runner, counts := range prev.Runners {
+		if _, ok := s.Runners[runner]; !ok {
+			delta.Runners[runner] = Counts{}.delta(counts)
+		}
+	}
+
+	return delta
+}
+
+func (c Counts) delta(prev Counts) Counts {
+	delta := Counts{}
+	for _, status := range Statuses {
+		delta[status] = c[status] - prev[status]
+	}
+
+	return delta
+}
+
+// filterStatuses drops the metrics (such as active) that aren't task statuses
+func filterStatuses(counts Counts) Counts {
+	filtered := Counts{}
+	for _, status := range Statuses {
+		filtered[status] = counts[status]
+	}
+
+	return filtered
+}
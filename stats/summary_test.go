@@ -0,0 +1,156 @@
+package stats
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	firstScrape = `# HELP taask_tasks_queued Tasks currently queued
+# TYPE taask_tasks_queued gauge
+taask_tasks_queued{kind="io.taask.k8s",runner="RUNNERA"} 3
+taask_tasks_queued{kind="io.taask.k8s",runner=""} 1
+taask_tasks_running{kind="io.taask.k8s",runner="RUNNERA"} 2
+taask_tasks_waiting{kind="io.taask.docker",runner=""} 7
+# TYPE taask_tasks_complete counter
+taask_tasks_complete{kind="io.taask.k8s",runner="RUNNERA"} 100
+taask_tasks_complete{kind="io.taask.docker",runner="RUNNERB"} 5
+# TYPE taask_tasks_active gauge
+taask_tasks_active{kind="io.taask.k8s",runner="RUNNERA"} 12
+taask_tasks_active{kind="io.taask.docker",runner="RUNNERB"} 4
+go_goroutines 10
+`
+
+	// RUNNERB and the docker kind are gone, RUNNERC and the lambda kind are new
+	secondScrape = `taask_tasks_queued{kind="io.taask.k8s",runner="RUNNERA"} 1
+taask_tasks_running{kind="io.taask.k8s",runner="RUNNERA"} 4
+taask_tasks_complete{kind="io.taask.k8s",runner="RUNNERA"} 110
+taask_tasks_failed{kind="io.taask.lambda",runner="RUNNERC"} 2
+taask_tasks_active{kind="io.taask.lambda",runner="RUNNERC"} 1
+`
+)
+
+// counts builds Counts in the order of Statuses
+func counts(values ...float64) Counts {
+	c := Counts{}
+	for i, status := range Statuses {
+		c[status] = values[i]
+	}
+
+	return c
+}
+
+func parseSummary(t *testing.T, text string) *Summary {
+	parser := &expfmt.TextParser{}
+
+	families, err := parser.TextToMetricFamilies(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("failed to TextToMetricFamilies: %s", err)
+	}
+
+	summary := Summarize(snapshotFromFamilies(families))
+	summary.Time = time.Time{}
+
+	return summary
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want *Summary
+	}{
+		{
+			name: "active is filtered out",
+			text: firstScrape,
+			want: &Summary{
+				Totals: counts(7, 4, 2, 0, 105, 0),
+				Kinds: map[string]Counts{
+					"io.taask.k8s":    counts(0, 4, 2, 0, 100, 0),
+					"io.taask.docker": counts(7, 0, 0, 0, 5, 0),
+				},
+				Runners: map[string]Counts{
+					"RUNNERA": counts(0, 3, 2, 0, 100, 0),
+					"RUNNERB": counts(0, 0, 0, 0, 5, 0),
+				},
+			},
+		},
+		{
+			name: "no task metrics",
+			text: "go_goroutines 10\n",
+			want: &Summary{
+				Totals:  counts(0, 0, 0, 0, 0, 0),
+				Kinds:   map[string]Counts{},
+				Runners: map[string]Counts{},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseSummary(t, test.text)
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDelta(t *testing.T) {
+	tests := []struct {
+		name string
+		prev string
+		cur  string
+		want *Summary
+	}{
+		{
+			name: "unchanged",
+			prev: firstScrape,
+			cur:  firstScrape,
+			want: &Summary{
+				Totals: counts(0, 0, 0, 0, 0, 0),
+				Kinds: map[string]Counts{
+					"io.taask.k8s":    counts(0, 0, 0, 0, 0, 0),
+					"io.taask.docker": counts(0, 0, 0, 0, 0, 0),
+				},
+				Runners: map[string]Counts{
+					"RUNNERA": counts(0, 0, 0, 0, 0, 0),
+					"RUNNERB": counts(0, 0, 0, 0, 0, 0),
+				},
+			},
+		},
+		{
+			name: "kinds and runners that appear and disappear",
+			prev: firstScrape,
+			cur:  secondScrape,
+			want: &Summary{
+				Totals: counts(-7, -3, 2, 0, 5, 2),
+				Kinds: map[string]Counts{
+					"io.taask.k8s":    counts(0, -3, 2, 0, 10, 0),
+					"io.taask.docker": counts(-7, 0, 0, 0, -5, 0),
+					"io.taask.lambda": counts(0, 0, 0, 0, 0, 2),
+				},
+				Runners: map[string]Counts{
+					"RUNNERA": counts(0, -2, 2, 0, 10, 0),
+					"RUNNERB": counts(0, 0, 0, 0, -5, 0),
+					"RUNNERC": counts(0, 0, 0, 0, 0, 2),
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseSummary(t, test.cur).Delta(parseSummary(t, test.prev))
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
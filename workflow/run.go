@@ -0,0 +1,186 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+
+	log "github.com/cohix/simplog"
+	"github.com/pkg/errors"
+	taask "github.com/taask/client-golang"
+)
+
+type stepResult struct {
+	name   string
+	result json.RawMessage
+	err    error
+}
+
+// Run executes the steps of wf that are not already complete in state, running steps in parallel
+// as soon as their dependencies have completed. Steps that depend on a failed step are skipped.
+// The state is written to disk every time a step changes, and onSubmit (if not nil) is called for every task created.
+func Run(client *taask.Client, wf *Workflow, state *State, onSubmit func(step, uuid string, spec taask.Task)) error {
+	results := make(map[string]interface{})
+	for name, step := range state.Steps {
+		if step.Status == StepStatusComplete {
+			var result interface{}
+			if err := json.Unmarshal(step.Result, &result); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("failed to Unmarshal stored result of step %s", name))
+			}
+
+			results[name] = result
+		}
+	}
+
+	doneChan := make(chan stepResult, len(wf.Steps))
+	watching := make(map[string]bool)
+
+	for {
+		for _, s := range wf.Steps {
+			stepState := state.Steps[s.Name]
+
+			if stepState.Status == StepStatusRunning && stepState.UUID != "" && !watching[s.Name] {
+				// resuming a step that was submitted by a previous run
+				log.LogInfo(fmt.Sprintf("step %s: resuming task %s", s.Name, stepState.UUID))
+				watching[s.Name] = true
+				go watchStep(client, s.Name, stepState.UUID, doneChan)
+				continue
+			}
+
+			if stepState.Status != StepStatusPending {
+				continue
+			}
+
+			if failedDep := state.failedDependency(s); failedDep != "" {
+				stepState.Status = StepStatusSkipped
+				stepState.Error = fmt.Sprintf("dependency %s did not complete", failedDep)
+				log.LogWarn(fmt.Sprintf("step %s: skipped, %s", s.Name, stepState.Error))
+				continue
+			}
+
+			if !state.dependenciesComplete(s) {
+				continue
+			}
+
+			body, err := RenderBody(s.Spec.Body, results)
+			if err != nil {
+				stepState.Status = StepStatusFailed
+				stepState.Error = errors.Wrap(err, "failed to RenderBody").Error()
+				log.LogError(fmt.Errorf("step %s: %s", s.Name, stepState.Error))
+				continue
+			}
+
+			spec := s.Spec
+			spec.Body = body
+
+			uuid, err := client.SendSpecTask(spec)
+			if err != nil {
+				stepState.Status = StepStatusFailed
+				stepState.Error = errors.Wrap(err, "failed to SendSpecTask").Error()
+				log.LogError(fmt.Errorf("step %s: %s", s.Name, stepState.Error))
+				continue
+			}
+
+			log.LogInfo(fmt.Sprintf("step %s: created task %s", s.Name, uuid))
+			if onSubmit != nil {
+				onSubmit(s.Name, uuid, spec)
+			}
+
+			stepState.Status = StepStatusRunning
+			stepState.UUID = uuid
+			watching[s.Name] = true
+			go watchStep(client, s.Name, uuid, doneChan)
+		}
+
+		if err := state.Write(); err != nil {
+			return errors.Wrap(err, "failed to Write state")
+		}
+
+		if len(watching) == 0 {
+			if state.hasPending() {
+				// a step that failed or was skipped during this pass may leave dependents that now need skipping
+				continue
+			}
+
+			break
+		}
+
+		done := <-doneChan
+		delete(watching, done.name)
+
+		stepState := state.Steps[done.name]
+
+		if done.err != nil {
+			stepState.Status = StepStatusFailed
+			stepState.Error = done.err.Error()
+			log.LogError(fmt.Errorf("step %s: %s", done.name, stepState.Error))
+			continue
+		}
+
+		var result interface{}
+		if err := json.Unmarshal(done.result, &result); err != nil {
+			stepState.Status = StepStatusFailed
+			stepState.Error = errors.Wrap(err, "failed to Unmarshal result").Error()
+			log.LogError(fmt.Errorf("step %s: %s", done.name, stepState.Error))
+			continue
+		}
+
+		stepState.Status = StepStatusComplete
+		stepState.Result = done.result
+		results[done.name] = result
+
+		log.LogInfo(fmt.Sprintf("step %s: complete", done.name))
+	}
+
+	failed := 0
+	for _, step := range state.Steps {
+		if step.Status != StepStatusComplete {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d steps did not complete", failed, len(state.Steps))
+	}
+
+	return nil
+}
+
+func watchStep(client *taask.Client, name, uuid string, doneChan chan stepResult) {
+	result, err := client.StreamTaskResult(uuid)
+	if err != nil {
+		err = errors.Wrap(err, "failed to StreamTaskResult")
+	}
+
+	doneChan <- stepResult{name: name, result: result, err: err}
+}
+
+func (s *State) failedDependency(step Step) string {
+	for _, dep := range step.DependsOn {
+		status := s.Steps[dep].Status
+		if status == StepStatusFailed || status == StepStatusSkipped {
+			return dep
+		}
+	}
+
+	return ""
+}
+
+func (s *State) dependenciesComplete(step Step) bool {
+	for _, dep := range step.DependsOn {
+		if s.Steps[dep].Status != StepStatusComplete {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *State) hasPending() bool {
+	for _, step := range s.Steps {
+		if step.Status == StepStatusPending {
+			return true
+		}
+	}
+
+	return false
+}
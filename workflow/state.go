@@ -0,0 +1,113 @@
+package workflow
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/taask/client-golang/config"
+)
+
+// StepStatusPending and others are the states a workflow step can be in
+const (
+	StepStatusPending  = "pending"
+	StepStatusRunning  = "running"
+	StepStatusComplete = "complete"
+	StepStatusFailed   = "failed"
+	StepStatusSkipped  = "skipped" // a step the workflow will not run because one of its dependencies failed
+
+	workflowStateDir = "workflows"
+)
+
+// State records the progress of a workflow run so that it can be inspected and resumed
+type State struct {
+	Name  string
+	File  string
+	Steps map[string]*StepState
+}
+
+// StepState records the progress of a single step
+type StepState struct {
+	Status string
+	UUID   string          `json:",omitempty"`
+	Result json.RawMessage `json:",omitempty"`
+	Error  string          `json:",omitempty"`
+}
+
+// NewState creates an empty state for a workflow
+func NewState(wf *Workflow, file string) *State {
+	state := &State{
+		Name:  wf.Name,
+		File:  file,
+		Steps: make(map[string]*StepState),
+	}
+
+	for _, s := range wf.Steps {
+		state.Steps[s.Name] = &StepState{Status: StepStatusPending}
+	}
+
+	return state
+}
+
+// StatePath returns the path that the state for a workflow named name is stored at
+func StatePath(name string) string {
+	return filepath.Join(config.DefaultClientConfigDir(), workflowStateDir, name+".json")
+}
+
+// ReadState reads the stored state of the workflow named name
+func ReadState(name string) (*State, error) {
+	raw, err := ioutil.ReadFile(StatePath(name))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to ReadFile")
+	}
+
+	state := &State{}
+	if err := json.Unmarshal(raw, state); err != nil {
+		return nil, errors.Wrap(err, "failed to Unmarshal")
+	}
+
+	return state, nil
+}
+
+// Write stores the state to disk
+func (s *State) Write() error {
+	path := StatePath(s.Name)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrap(err, "failed to MkdirAll")
+	}
+
+	raw, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "failed to MarshalIndent")
+	}
+
+	if err := ioutil.WriteFile(path, raw, 0600); err != nil {
+		return errors.Wrap(err, "failed to WriteFile")
+	}
+
+	return nil
+}
+
+// Merge prepares a stored state to be resumed for wf, keeping completed steps and
+// steps that are still running, and resetting anything that failed or was skipped
+func (s *State) Merge(wf *Workflow) {
+	fresh := NewState(wf, s.File)
+
+	for name, step := range fresh.Steps {
+		old, ok := s.Steps[name]
+		if !ok {
+			continue
+		}
+
+		if old.Status == StepStatusComplete || (old.Status == StepStatusRunning && old.UUID != "") {
+			fresh.Steps[name] = old
+		} else {
+			fresh.Steps[name] = step
+		}
+	}
+
+	s.Steps = fresh.Steps
+}
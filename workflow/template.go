@@ -0,0 +1,152 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/taask/taaskctl/readwrite"
+)
+
+// matches {{ steps.<name>.result }} and {{ steps.<name>.result.<field>.<field> }}
+var templateRegex = regexp.MustCompile(`\{\{\s*steps\.([A-Za-z0-9_-]+)\.result((?:\.[A-Za-z0-9_-]+)*)\s*\}\}`)
+
+// RenderBody replaces result templates in a step's body with values from the results of previous steps.
+// A string that consists only of a template is replaced with the referenced value (keeping its type),
+// otherwise the value is formatted into the string.
+func RenderBody(body map[string]interface{}, results map[string]interface{}) (map[string]interface{}, error) {
+	rendered, err := render(readwrite.NormalizeValue(body), results)
+	if err != nil {
+		return nil, err
+	}
+
+	return rendered.(map[string]interface{}), nil
+}
+
+// TemplateSteps returns the names of the steps whose results are used by templates in a body
+func TemplateSteps(body interface{}) []string {
+	names := []string{}
+
+	var walk func(val interface{})
+	walk = func(val interface{}) {
+		switch v := val.(type) {
+		case map[string]interface{}:
+			for _, inner := range v {
+				walk(inner)
+			}
+		case map[interface{}]interface{}:
+			for _, inner := range v {
+				walk(inner)
+			}
+		case []interface{}:
+			for _, inner := range v {
+				walk(inner)
+			}
+		case string:
+			for _, match := range templateRegex.FindAllStringSubmatch(v, -1) {
+				names = append(names, match[1])
+			}
+		}
+	}
+
+	walk(body)
+
+	return names
+}
+
+// render replaces the templates in a value normalized by readwrite.NormalizeValue
+func render(val interface{}, results map[string]interface{}) (interface{}, error) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, inner := range v {
+			r, err := render(inner, results)
+			if err != nil {
+				return nil, err
+			}
+
+			out[key] = r
+		}
+
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, inner := range v {
+			r, err := render(inner, results)
+			if err != nil {
+				return nil, err
+			}
+
+			out[i] = r
+		}
+
+		return out, nil
+	case string:
+		return renderString(v, results)
+	}
+
+	return val, nil
+}
+
+func renderString(str string, results map[string]interface{}) (interface{}, error) {
+	if match := templateRegex.FindStringSubmatch(str); match != nil && match[0] == strings.TrimSpace(str) {
+		return lookup(match[1], match[2], results)
+	}
+
+	var lookupErr error
+
+	out := templateRegex.ReplaceAllStringFunc(str, func(tmpl string) string {
+		match := templateRegex.FindStringSubmatch(tmpl)
+
+		val, err := lookup(match[1], match[2], results)
+		if err != nil {
+			lookupErr = err
+			return tmpl
+		}
+
+		if s, ok := val.(string); ok {
+			return s
+		}
+
+		valJSON, err := json.Marshal(val)
+		if err != nil {
+			lookupErr = errors.Wrap(err, "failed to Marshal template value")
+			return tmpl
+		}
+
+		return string(valJSON)
+	})
+
+	if lookupErr != nil {
+		return nil, lookupErr
+	}
+
+	return out, nil
+}
+
+func lookup(step, path string, results map[string]interface{}) (interface{}, error) {
+	val, ok := results[step]
+	if !ok {
+		return nil, fmt.Errorf("result for step %s is not available", step)
+	}
+
+	for _, field := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		if field == "" {
+			continue
+		}
+
+		obj, ok := val.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("result of step %s has no field %s", step, field)
+		}
+
+		val, ok = obj[field]
+		if !ok {
+			return nil, fmt.Errorf("result of step %s has no field %s", step, field)
+		}
+	}
+
+	return val, nil
+}
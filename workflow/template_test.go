@@ -0,0 +1,133 @@
+package workflow
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenderBody(t *testing.T) {
+	results := map[string]interface{}{
+		"first": map[string]interface{}{
+			"Answer": 42.0,
+			"Name":   "deep thought",
+			"Nested": map[string]interface{}{"List": []interface{}{1.0, 2.0}},
+		},
+		"plain": "just a string",
+	}
+
+	tests := []struct {
+		name    string
+		body    map[string]interface{}
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "no templates",
+			body: map[string]interface{}{"a": "b", "n": 1},
+			want: map[string]interface{}{"a": "b", "n": 1},
+		},
+		{
+			name: "whole string keeps the value's type",
+			body: map[string]interface{}{"answer": "{{ steps.first.result.Answer }}"},
+			want: map[string]interface{}{"answer": 42.0},
+		},
+		{
+			name: "whole string without spaces or with surrounding whitespace",
+			body: map[string]interface{}{"a": "{{steps.first.result.Answer}}", "b": "  {{ steps.first.result.Answer }} "},
+			want: map[string]interface{}{"a": 42.0, "b": 42.0},
+		},
+		{
+			name: "whole result",
+			body: map[string]interface{}{"result": "{{ steps.plain.result }}"},
+			want: map[string]interface{}{"result": "just a string"},
+		},
+		{
+			name: "nested field",
+			body: map[string]interface{}{"list": "{{ steps.first.result.Nested.List }}"},
+			want: map[string]interface{}{"list": []interface{}{1.0, 2.0}},
+		},
+		{
+			name: "templates inside a string are formatted",
+			body: map[string]interface{}{"msg": "{{ steps.first.result.Name }} says {{ steps.first.result.Answer }}"},
+			want: map[string]interface{}{"msg": "deep thought says 42"},
+		},
+		{
+			name: "objects inside a string are formatted as JSON",
+			body: map[string]interface{}{"msg": "got {{ steps.first.result.Nested }}"},
+			want: map[string]interface{}{"msg": `got {"List":[1,2]}`},
+		},
+		{
+			name: "yaml interface-keyed maps and lists are rendered",
+			body: map[string]interface{}{
+				"outer": map[interface{}]interface{}{
+					"inner": []interface{}{"{{ steps.first.result.Answer }}", map[interface{}]interface{}{1: "x"}},
+				},
+			},
+			want: map[string]interface{}{
+				"outer": map[string]interface{}{
+					"inner": []interface{}{42.0, map[string]interface{}{"1": "x"}},
+				},
+			},
+		},
+		{
+			name:    "unknown step",
+			body:    map[string]interface{}{"a": "{{ steps.missing.result }}"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			body:    map[string]interface{}{"a": "{{ steps.first.result.Missing }}"},
+			wantErr: true,
+		},
+		{
+			name:    "field of a value that isn't an object",
+			body:    map[string]interface{}{"a": "{{ steps.plain.result.Field }}"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown step inside a string",
+			body:    map[string]interface{}{"a": "value: {{ steps.missing.result }}"},
+			wantErr: true,
+		},
+		{
+			name: "text that isn't a step template is left alone",
+			body: map[string]interface{}{"a": "{{ other.thing }}"},
+			want: map[string]interface{}{"a": "{{ other.thing }}"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := RenderBody(test.body, results)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRenderBodyDoesNotModifyBody(t *testing.T) {
+	body := map[string]interface{}{
+		"nested": map[string]interface{}{"a": "{{ steps.first.result }}"},
+	}
+
+	if _, err := RenderBody(body, map[string]interface{}{"first": "done"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := body["nested"].(map[string]interface{})["a"]; got != "{{ steps.first.result }}" {
+		t.Errorf("body was modified, nested.a is %v", got)
+	}
+}
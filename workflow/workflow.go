@@ -0,0 +1,168 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	taask "github.com/taask/client-golang"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// WorkflowType and others are consts for workflow files
+const (
+	WorkflowVersion = 1
+	WorkflowType    = "io.taask.workflow"
+)
+
+// Workflow describes a set of named task steps and the dependencies between them
+type Workflow struct {
+	Version int
+	Type    string
+	Name    string
+	Steps   []Step
+}
+
+// Step is a single task in a workflow
+type Step struct {
+	Name      string
+	DependsOn []string `yaml:"dependsOn" json:"dependsOn"`
+	Spec      taask.Task
+}
+
+// ReadWorkflowFile reads a file and converts it to a workflow
+func ReadWorkflowFile(path string) (*Workflow, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to ReadFile")
+	}
+
+	wf := &Workflow{}
+	if err := yaml.Unmarshal(raw, wf); err != nil {
+		if jsonErr := json.Unmarshal(raw, wf); jsonErr != nil {
+			return nil, errors.Wrap(jsonErr, errors.Wrap(err, "failed to yaml and json Unmarshal").Error()) // stupid, but whatever
+		}
+	}
+
+	if wf.Name == "" {
+		wf.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	if err := wf.Validate(); err != nil {
+		return nil, errors.Wrap(err, "failed to Validate")
+	}
+
+	return wf, nil
+}
+
+// Validate checks the workflow's type and version, that step names are unique, dependencies exist, the steps form a DAG,
+// and that steps only use the results of steps they depend on
+func (w *Workflow) Validate() error {
+	if w.Type != "" && w.Type != WorkflowType {
+		return fmt.Errorf("unknown workflow type %s", w.Type)
+	}
+
+	// like the type, the version can be left out
+	if w.Version != 0 && w.Version != WorkflowVersion {
+		return fmt.Errorf("workflow version %d is not supported, this taaskctl supports version %d", w.Version, WorkflowVersion)
+	}
+
+	if len(w.Steps) == 0 {
+		return errors.New("workflow has no steps")
+	}
+
+	steps := make(map[string]Step)
+	for _, s := range w.Steps {
+		if s.Name == "" {
+			return errors.New("workflow step is missing a name")
+		}
+
+		if _, exists := steps[s.Name]; exists {
+			return fmt.Errorf("step %s is defined more than once", s.Name)
+		}
+
+		steps[s.Name] = s
+	}
+
+	for _, s := range w.Steps {
+		for _, dep := range s.DependsOn {
+			if _, exists := steps[dep]; !exists {
+				return fmt.Errorf("step %s depends on unknown step %s", s.Name, dep)
+			}
+		}
+	}
+
+	// depth-first search, a step seen again while still on the stack means a cycle
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	marks := make(map[string]int)
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch marks[name] {
+		case visiting:
+			return fmt.Errorf("step %s is part of a dependency cycle", name)
+		case visited:
+			return nil
+		}
+
+		marks[name] = visiting
+		for _, dep := range steps[name].DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		marks[name] = visited
+
+		return nil
+	}
+
+	for _, s := range w.Steps {
+		if err := visit(s.Name); err != nil {
+			return err
+		}
+	}
+
+	// a step's templates can only use the results of steps that are guaranteed to have finished before it runs
+	for _, s := range w.Steps {
+		deps := dependencies(s.Name, steps)
+
+		for _, name := range TemplateSteps(s.Spec.Body) {
+			if _, exists := steps[name]; !exists {
+				return fmt.Errorf("step %s uses the result of unknown step %s", s.Name, name)
+			}
+
+			if !deps[name] {
+				return fmt.Errorf("step %s uses the result of step %s, but does not depend on it", s.Name, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// dependencies returns the steps that name depends on directly or transitively, the steps must not form a cycle
+func dependencies(name string, steps map[string]Step) map[string]bool {
+	deps := make(map[string]bool)
+
+	var collect func(name string)
+	collect = func(name string) {
+		for _, dep := range steps[name].DependsOn {
+			if !deps[dep] {
+				deps[dep] = true
+				collect(dep)
+			}
+		}
+	}
+
+	collect(name)
+
+	return deps
+}
@@ -0,0 +1,126 @@
+package workflow
+
+import (
+	"testing"
+
+	taask "github.com/taask/client-golang"
+)
+
+func TestValidate(t *testing.T) {
+	step := func(name string, dependsOn ...string) Step {
+		return Step{Name: name, DependsOn: dependsOn}
+	}
+
+	// templateStep is a step whose body uses tmpl, nested the way yaml decodes it
+	templateStep := func(name, tmpl string, dependsOn ...string) Step {
+		s := step(name, dependsOn...)
+		s.Spec = taask.Task{Body: map[string]interface{}{
+			"outer": map[interface{}]interface{}{"list": []interface{}{"value: " + tmpl}},
+		}}
+
+		return s
+	}
+
+	tests := []struct {
+		name    string
+		wf      Workflow
+		wantErr bool
+	}{
+		{
+			name: "single step",
+			wf:   Workflow{Steps: []Step{step("a")}},
+		},
+		{
+			name: "diamond",
+			wf:   Workflow{Steps: []Step{step("d", "b", "c"), step("b", "a"), step("c", "a"), step("a")}},
+		},
+		{
+			name: "current version and type",
+			wf:   Workflow{Version: WorkflowVersion, Type: WorkflowType, Steps: []Step{step("a")}},
+		},
+		{
+			name:    "unknown type",
+			wf:      Workflow{Type: "io.taask.other", Steps: []Step{step("a")}},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported version",
+			wf:      Workflow{Version: WorkflowVersion + 1, Steps: []Step{step("a")}},
+			wantErr: true,
+		},
+		{
+			name:    "no steps",
+			wf:      Workflow{},
+			wantErr: true,
+		},
+		{
+			name:    "step without a name",
+			wf:      Workflow{Steps: []Step{step("")}},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate step",
+			wf:      Workflow{Steps: []Step{step("a"), step("a")}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown dependency",
+			wf:      Workflow{Steps: []Step{step("a", "missing")}},
+			wantErr: true,
+		},
+		{
+			name:    "depends on itself",
+			wf:      Workflow{Steps: []Step{step("a", "a")}},
+			wantErr: true,
+		},
+		{
+			name:    "cycle",
+			wf:      Workflow{Steps: []Step{step("a", "c"), step("b", "a"), step("c", "b")}},
+			wantErr: true,
+		},
+		{
+			name: "template using a direct dependency",
+			wf:   Workflow{Steps: []Step{step("a"), templateStep("b", "{{ steps.a.result.y }}", "a")}},
+		},
+		{
+			name: "template using a transitive dependency",
+			wf:   Workflow{Steps: []Step{step("a"), step("b", "a"), templateStep("c", "{{ steps.a.result }}", "b")}},
+		},
+		{
+			name:    "template using a step it doesn't depend on",
+			wf:      Workflow{Steps: []Step{step("a"), templateStep("b", "{{ steps.a.result.y }}")}},
+			wantErr: true,
+		},
+		{
+			name:    "template using a step that depends on it",
+			wf:      Workflow{Steps: []Step{templateStep("a", "{{ steps.b.result }}"), step("b", "a")}},
+			wantErr: true,
+		},
+		{
+			name:    "template using its own step",
+			wf:      Workflow{Steps: []Step{templateStep("a", "{{ steps.a.result }}")}},
+			wantErr: true,
+		},
+		{
+			name:    "template using an unknown step",
+			wf:      Workflow{Steps: []Step{step("a"), templateStep("b", "{{ steps.nope.result }}", "a")}},
+			wantErr: true,
+		},
+		{
+			name:    "cycle not reachable from the first step",
+			wf:      Workflow{Steps: []Step{step("a"), step("b", "c"), step("c", "b")}},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.wf.Validate()
+			if test.wantErr && err == nil {
+				t.Error("expected an error")
+			} else if !test.wantErr && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		})
+	}
+}